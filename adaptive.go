@@ -0,0 +1,88 @@
+package topk
+
+// AdaptiveSketch is a [Sketch] that starts with a modest width and doubles it (via [Sketch.Resize])
+// whenever its [Sketch.Saturation] crosses a threshold, up to a maximum width.
+// This bounds the memory used for small streams while scaling to high-cardinality ones.
+//
+// Saturation is checked by [AdaptiveSketch.Add] and [AdaptiveSketch.Incr] once every CheckInterval calls;
+// other ways of counting (e.g. [Sketch.AddExact]) only take effect on the next check.
+type AdaptiveSketch struct {
+	*Sketch
+
+	MaxWidth            int     // The width is never grown beyond MaxWidth.
+	SaturationThreshold float64 // The width is doubled once the saturation exceeds this fraction.
+	CheckInterval       int     // Number of adds between saturation checks.
+
+	sketchOptions  []Option
+	addsSinceCheck int
+}
+
+// AdaptiveOption configures an [AdaptiveSketch].
+type AdaptiveOption func(*AdaptiveSketch)
+
+// WithSketchOptions sets the options of the underlying sketch. The width set here is the initial width.
+func WithSketchOptions(opts ...Option) AdaptiveOption {
+	return func(s *AdaptiveSketch) { s.sketchOptions = append(s.sketchOptions, opts...) }
+}
+
+// WithMaxWidth sets the maximum width of an adaptive sketch.
+func WithMaxWidth(width int) AdaptiveOption {
+	return func(s *AdaptiveSketch) { s.MaxWidth = width }
+}
+
+// WithSaturationThreshold sets the saturation (fraction of buckets in use) above which an adaptive sketch doubles its width.
+func WithSaturationThreshold(threshold float64) AdaptiveOption {
+	return func(s *AdaptiveSketch) { s.SaturationThreshold = threshold }
+}
+
+// WithCheckInterval sets the number of adds between saturation checks of an adaptive sketch.
+func WithCheckInterval(n int) AdaptiveOption {
+	return func(s *AdaptiveSketch) { s.CheckInterval = n }
+}
+
+// NewAdaptive returns an adaptive top-k sketch with the given `k` (number of top items to keep).
+//
+//   - The underlying sketch uses the defaults of [New] unless the [WithSketchOptions] option is set.
+//   - The maximum width defaults to 16 times the initial width unless the [WithMaxWidth] option is set.
+//   - The saturation threshold defaults to 0.7 unless the [WithSaturationThreshold] option is set.
+//   - The check interval defaults to the initial width unless the [WithCheckInterval] option is set,
+//     which amortizes the cost of computing the saturation to O(depth) per add.
+func NewAdaptive(k int, opts ...AdaptiveOption) *AdaptiveSketch {
+	out := AdaptiveSketch{
+		SaturationThreshold: 0.7,
+	}
+	for _, o := range opts {
+		o(&out)
+	}
+	out.Sketch = New(k, out.sketchOptions...)
+	if out.MaxWidth == 0 {
+		out.MaxWidth = 16 * out.Width
+	}
+	if out.CheckInterval == 0 {
+		out.CheckInterval = out.Width
+	}
+	return &out
+}
+
+// Incr counts a single instance of the given item.
+func (me *AdaptiveSketch) Incr(item string) bool {
+	return me.Add(item, 1)
+}
+
+// Add increments the given item's count by the given increment, growing the sketch if it has become saturated.
+// Returns whether the item is in the top K.
+func (me *AdaptiveSketch) Add(item string, increment uint32) bool {
+	me.addsSinceCheck++
+	if me.addsSinceCheck >= me.CheckInterval {
+		me.addsSinceCheck = 0
+		me.maybeGrow()
+	}
+	return me.Sketch.Add(item, increment)
+}
+
+func (me *AdaptiveSketch) maybeGrow() {
+	if me.Width >= me.MaxWidth || me.Saturation() <= me.SaturationThreshold {
+		return
+	}
+	me.Resize(min(2*me.Width, me.MaxWidth))
+}
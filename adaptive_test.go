@@ -0,0 +1,72 @@
+package topk_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestAdaptiveSketch(t *testing.T) {
+	sketch := topk.NewAdaptive(5,
+		topk.WithSketchOptions(topk.WithWidth(64), topk.WithDepth(3)),
+		topk.WithMaxWidth(1024),
+	)
+
+	heavy := []string{"heavy0", "heavy1", "heavy2"}
+	widths := map[int]bool{sketch.Width: true}
+	for i := range 200_000 {
+		sketch.Incr(fmt.Sprintf("noise%d", i))
+		if i%10 < 3 {
+			sketch.Add(heavy[i%10], 5)
+		}
+		widths[sketch.Width] = true
+	}
+
+	for _, width := range []int{64, 128, 256, 512, 1024} {
+		if !widths[width] {
+			t.Errorf("Expected the sketch to pass through width %d, got widths %v", width, widths)
+		}
+	}
+	if sketch.Width != 1024 {
+		t.Errorf("Expected the width to stop at the cap 1024, got %d", sketch.Width)
+	}
+	if len(sketch.Buckets) != 1024*3 {
+		t.Errorf("Expected %d buckets, got %d", 1024*3, len(sketch.Buckets))
+	}
+	for _, item := range heavy {
+		if !sketch.Query(item) {
+			t.Errorf("Expected %s to stay in the top K across grows", item)
+		}
+	}
+}
+
+func TestAdaptiveSketch_LowCardinality(t *testing.T) {
+	sketch := topk.NewAdaptive(5, topk.WithSketchOptions(topk.WithWidth(64), topk.WithDepth(3)))
+	for i := range 10_000 {
+		sketch.Incr(fmt.Sprintf("item%d", i%10))
+	}
+	if sketch.Width != 64 {
+		t.Errorf("Expected the width to stay at 64 for 10 distinct items, got %d", sketch.Width)
+	}
+}
+
+func TestSketch_Resize(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(16), topk.WithDepth(2), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Add("b", 20)
+
+	sketch.Resize(256)
+
+	if len(sketch.Buckets) != 256*2 {
+		t.Fatalf("Expected %d buckets, got %d", 256*2, len(sketch.Buckets))
+	}
+	for item, count := range map[string]uint32{"a": 10, "b": 20} {
+		if c := sketch.Count(item); c != count {
+			t.Errorf("Expected Count(%s) = %d after Resize, got %d", item, count, c)
+		}
+	}
+	if s := sketch.Saturation(); s != 4.0/(256*2) {
+		t.Errorf("Expected saturation %v, got %v", 4.0/(256*2), s)
+	}
+}
@@ -0,0 +1,70 @@
+package topk
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/keilerkonzept/topk/internal/sizeof"
+)
+
+const (
+	// budgetKeyBytes is the average item string length that [WidthForBudget] reserves memory for in the top-K heap.
+	budgetKeyBytes = 32
+	// minSuggestedSampleRate is the smallest rate that [SuggestSampleRate] suggests.
+	minSuggestedSampleRate = 0.001
+)
+
+// WidthForBudget returns the largest width for which a sketch with the given K, the default depth of [New], and the default decay LUT
+// stays within budgetBytes (as reported by [Sketch.SizeBytes]) once its top-K heap is full,
+// assuming that the item strings in the heap are at most 32 bytes long on average.
+// It returns 0 if the heap alone exceeds the budget.
+func WidthForBudget(k, budgetBytes int) int {
+	depth := max(3, int(math.Log(float64(k))))
+	fixed := EstimateSizeBytes(k, 0, depth, 0) + k*(sizeof.Int+sizeof.String+budgetKeyBytes)
+	if budgetBytes <= fixed {
+		return 0
+	}
+	return (budgetBytes - fixed) / (depth * sizeofBucketStruct)
+}
+
+// SuggestSampleRate suggests a sampling rate (see [WithSampling]) for counting a stream of targetOps adds (per sketch lifetime or window)
+// into a sketch that is sized with [WidthForBudget](k, budgetBytes) to stay within the memory budget.
+//
+// The heuristic: a sketch's memory is fixed by its width, but its accuracy degrades once most buckets are contended by several items.
+// In the worst case every add is of a distinct item, and n distinct items occupy about `1 - e^(-n/width)` of each row's buckets.
+// The suggested rate samples at most `width` adds, which keeps the occupancy at about 63% or below even in the worst case,
+// and is 1 (no sampling) if targetOps doesn't exceed the width.
+// Since sampling multiplies the variance of the counts by `1/rate`, the suggested rate is never below 0.001.
+//
+// It returns 0 if the budget is too small for a sketch with the given K. Note that [WithSampling] treats 0 as "no sampling".
+func SuggestSampleRate(targetOps int, budgetBytes int, k int) float32 {
+	width := WidthForBudget(k, budgetBytes)
+	if width == 0 {
+		return 0
+	}
+	if targetOps <= width {
+		return 1
+	}
+	return max(minSuggestedSampleRate, float32(width)/float32(targetOps))
+}
+
+// NewForError returns a sketch like [New], but with its width and depth dimensioned for the given error bounds,
+// using the standard count-min sketch formulas:
+//
+//	Width = ⌈e / epsilon⌉
+//	Depth = ⌈ln(1 / delta)⌉
+//
+// For a count-min sketch, these guarantee that each estimate exceeds the true count by at most `epsilon` times the total count,
+// except with probability `delta`. HeavyKeeper doesn't over-estimate (except for fingerprint collisions),
+// but loses counts to decay on collisions instead; the formulas bound the share of the stream that collides with an item in all of its rows,
+// so they are a reasonable starting point for the under-estimation of heavy items, not a guarantee.
+// The given options are applied afterwards, so [WithWidth] and [WithDepth] override the computed dimensions.
+// Panics unless epsilon and delta are in (0, 1).
+func NewForError(k int, epsilon, delta float64, opts ...Option) *Sketch {
+	if !(epsilon > 0 && epsilon < 1) || !(delta > 0 && delta < 1) {
+		panic(fmt.Sprintf("topk: NewForError: epsilon and delta must be in (0, 1), got %v and %v", epsilon, delta))
+	}
+	width := int(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	return New(k, append([]Option{WithWidth(width), WithDepth(depth)}, opts...)...)
+}
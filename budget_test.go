@@ -0,0 +1,67 @@
+package topk_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSuggestSampleRate(t *testing.T) {
+	for _, tc := range []struct {
+		targetOps, budgetBytes, k int
+	}{
+		{targetOps: 10_000, budgetBytes: 1 << 20, k: 10},
+		{targetOps: 200_000, budgetBytes: 1 << 18, k: 50},
+		{targetOps: 500_000, budgetBytes: 1 << 16, k: 100},
+	} {
+		t.Run(fmt.Sprintf("Ops=%d_Budget=%d_K=%d", tc.targetOps, tc.budgetBytes, tc.k), func(t *testing.T) {
+			rate := topk.SuggestSampleRate(tc.targetOps, tc.budgetBytes, tc.k)
+			width := topk.WidthForBudget(tc.k, tc.budgetBytes)
+			if rate <= 0 || rate > 1 || width <= 0 {
+				t.Fatalf("Expected a rate in (0, 1] and a positive width, got %v and %d", rate, width)
+			}
+
+			// Worst case: every add is of a distinct item.
+			sketch := topk.New(tc.k, topk.WithWidth(width), topk.WithSampling(rate), topk.WithRand(rand.New(rand.NewPCG(1, 2))))
+			for i := range tc.targetOps {
+				sketch.Add(fmt.Sprintf("item_%d", i), 1)
+			}
+			if size := sketch.SizeBytes(); size > tc.budgetBytes {
+				t.Errorf("Expected the sketch to stay within %d bytes, got %d", tc.budgetBytes, size)
+			}
+			if saturation := sketch.Saturation(); saturation > 0.7 {
+				t.Errorf("Expected a saturation of at most 0.7, got %.2f (rate %v)", saturation, rate)
+			}
+		})
+	}
+
+	if rate := topk.SuggestSampleRate(100, 1<<20, 10); rate != 1 {
+		t.Errorf("Expected no sampling for a small stream, got %v", rate)
+	}
+	if rate := topk.SuggestSampleRate(100, 100, 10); rate != 0 {
+		t.Errorf("Expected 0 for a budget that doesn't fit the heap, got %v", rate)
+	}
+}
+
+func TestNewForError(t *testing.T) {
+	sketch := topk.NewForError(10, 0.01, 0.01)
+	if sketch.Width != 272 || sketch.Depth != 5 {
+		t.Errorf("Expected width 272 and depth 5, got %d and %d", sketch.Width, sketch.Depth)
+	}
+
+	prev := topk.NewForError(10, 0.1, 0.1)
+	for _, tc := range []struct{ epsilon, delta float64 }{{0.05, 0.02}, {0.01, 0.005}, {0.001, 0.0001}} {
+		sketch := topk.NewForError(10, tc.epsilon, tc.delta)
+		if sketch.Width <= prev.Width || sketch.Depth <= prev.Depth {
+			t.Errorf("Expected tighter bounds (%v, %v) to give a larger sketch than %dx%d, got %dx%d",
+				tc.epsilon, tc.delta, prev.Width, prev.Depth, sketch.Width, sketch.Depth)
+		}
+		prev = sketch
+	}
+
+	if sketch := topk.NewForError(10, 0.01, 0.01, topk.WithWidth(64)); sketch.Width != 64 || sketch.Depth != 5 {
+		t.Errorf("Expected WithWidth to override the width, got %dx%d", sketch.Width, sketch.Depth)
+	}
+}
@@ -0,0 +1,25 @@
+package topk
+
+import "github.com/keilerkonzept/topk/internal/unsafeutil"
+
+// IncrBytes is like [Sketch.Incr], but takes the item as a byte slice.
+func (me *Sketch) IncrBytes(item []byte) bool {
+	return me.AddBytes(item, 1)
+}
+
+// AddBytes is like [Sketch.Add], but takes the item as a byte slice.
+// The item is hashed without converting it to a string; a string copy is only allocated when the item enters the top-K heap,
+// so the slice may be reused by the caller after AddBytes returns.
+func (me *Sketch) AddBytes(item []byte, increment uint32) bool {
+	return me.add(unsafeutil.String(item), increment, false, true)
+}
+
+// CountBytes is like [Sketch.Count], but takes the item as a byte slice.
+func (me *Sketch) CountBytes(item []byte) uint32 {
+	return me.Count(unsafeutil.String(item))
+}
+
+// QueryBytes is like [Sketch.Query], but takes the item as a byte slice.
+func (me *Sketch) QueryBytes(item []byte) bool {
+	return me.Query(unsafeutil.String(item))
+}
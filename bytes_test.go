@@ -0,0 +1,41 @@
+package topk_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_AddBytes(t *testing.T) {
+	strs := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	bytes := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+
+	buf := make([]byte, 0, 16)
+	for i := range 1000 {
+		item := fmt.Sprintf("item%d", i%(1+i%20))
+		strs.Add(item, uint32(1+i%3))
+		// Reuse the buffer for every item, so that retained references to it would be overwritten.
+		buf = append(buf[:0], item...)
+		bytes.AddBytes(buf, uint32(1+i%3))
+	}
+	clear(buf[:cap(buf)])
+
+	if diff := cmp.Diff(strs.SortedSlice(), bytes.SortedSlice()); diff != "" {
+		t.Errorf("Top K mismatch (-strings +bytes):\n%s", diff)
+	}
+	for i := range 20 {
+		item := fmt.Sprintf("item%d", i)
+		if expected, actual := strs.Count(item), bytes.CountBytes([]byte(item)); actual != expected {
+			t.Errorf("Expected CountBytes(%s) = %d, got %d", item, expected, actual)
+		}
+		if expected, actual := strs.Query(item), bytes.QueryBytes([]byte(item)); actual != expected {
+			t.Errorf("Expected QueryBytes(%s) = %v, got %v", item, expected, actual)
+		}
+	}
+	if !bytes.IncrBytes([]byte("item1")) {
+		t.Error("Expected item1 to be in the top K")
+	}
+}
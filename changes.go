@@ -0,0 +1,48 @@
+package topk
+
+import "github.com/keilerkonzept/topk/heap"
+
+// rankedCount is an item's count and 1-based rank in the top K as of the last [Sketch.Snapshot] or [Sketch.ChangedSince].
+type rankedCount struct {
+	Count uint32
+	Rank  int
+}
+
+// Snapshot returns the top K items in descending count order (see [Sketch.SortedSlice]),
+// and records their counts and ranks as the baseline for the next [Sketch.ChangedSince].
+func (me *Sketch) Snapshot() []heap.Item {
+	items := me.SortedSlice()
+	me.recordSnapshot(items)
+	return items
+}
+
+// ChangedSince returns the top K items (in descending count order) whose count or rank changed since the last call to
+// ChangedSince or [Sketch.Snapshot], including the items that entered the top K since then, and records the current top K as the new baseline.
+// Before the first call, every item in the top K counts as changed.
+//
+// This is meant for pushing incremental updates to clients that poll the top K: unchanged items are omitted.
+// Items that left the top K are not reported; clients detect them by their rank, which is now held by another item,
+// or by ranks beyond the current number of items (see [Sketch.HeapStats]).
+// The baseline stores only the count and rank of each top-K item.
+func (me *Sketch) ChangedSince() []heap.Item {
+	items := me.SortedSlice()
+	var changed []heap.Item
+	for i, item := range items {
+		if prev, ok := me.snapshot[item.Item]; !ok || prev != (rankedCount{Count: item.Count, Rank: i + 1}) {
+			changed = append(changed, item)
+		}
+	}
+	me.recordSnapshot(items)
+	return changed
+}
+
+// recordSnapshot replaces the baseline of [Sketch.ChangedSince] with the given sorted top K items.
+func (me *Sketch) recordSnapshot(items []heap.Item) {
+	if me.snapshot == nil {
+		me.snapshot = make(map[string]rankedCount, len(items))
+	}
+	clear(me.snapshot)
+	for i, item := range items {
+		me.snapshot[item.Item] = rankedCount{Count: item.Count, Rank: i + 1}
+	}
+}
@@ -0,0 +1,52 @@
+package topk_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func TestSketch_ChangedSince(t *testing.T) {
+	sketch := topk.New(4, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	sketch.Add("c", 3)
+
+	item := func(item string, count uint32) heap.Item {
+		return heap.Item{Fingerprint: topk.Fingerprint(item), Item: item, Count: count}
+	}
+
+	// Before the first snapshot, every item has changed.
+	if diff := cmp.Diff(sketch.SortedSlice(), sketch.ChangedSince()); diff != "" {
+		t.Errorf("ChangedSince mismatch (-want +got):\n%s", diff)
+	}
+	if changed := sketch.ChangedSince(); len(changed) != 0 {
+		t.Errorf("Expected no changes without adds, got %v", changed)
+	}
+
+	// c's count changes, d enters the top K; a and b keep their counts and ranks.
+	sketch.Add("c", 1)
+	sketch.Add("d", 1)
+	expected := []heap.Item{item("c", 4), item("d", 1)}
+	if diff := cmp.Diff(expected, sketch.ChangedSince()); diff != "" {
+		t.Errorf("ChangedSince mismatch (-want +got):\n%s", diff)
+	}
+
+	// c overtakes b: both change rank, although only c's count changed.
+	sketch.Add("c", 2)
+	expected = []heap.Item{item("c", 6), item("b", 5)}
+	if diff := cmp.Diff(expected, sketch.ChangedSince()); diff != "" {
+		t.Errorf("ChangedSince mismatch (-want +got):\n%s", diff)
+	}
+
+	// Snapshot resets the baseline, too.
+	sketch.Add("d", 1)
+	if diff := cmp.Diff(sketch.SortedSlice(), sketch.Snapshot()); diff != "" {
+		t.Errorf("Snapshot mismatch (-want +got):\n%s", diff)
+	}
+	if changed := sketch.ChangedSince(); len(changed) != 0 {
+		t.Errorf("Expected no changes since the snapshot, got %v", changed)
+	}
+}
@@ -0,0 +1,87 @@
+// Command word_count counts the words read from standard input and prints the top K words with their estimated counts.
+//
+//	word_count [-k 10] [-format text|json|csv] < input.txt
+//
+// The text format prints one `item : count` line per word, the json format an array of `{"item": ..., "count": ...}` objects,
+// and the csv format an `item,count` header followed by one row per word. All formats list the words in descending count order.
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/keilerkonzept/topk"
+)
+
+// formats are the values of the -format flag.
+var formats = []string{"text", "json", "csv"}
+
+func main() {
+	k := flag.Int("k", 10, "number of top words to print")
+	format := flag.String("format", "text", "output format: text, json, or csv")
+	flag.Parse()
+	if !slices.Contains(formats, *format) {
+		fmt.Fprintf(flag.CommandLine.Output(), "unknown format %q, expected text, json, or csv\n", *format)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	sketch := topk.New(*k)
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Split(bufio.ScanWords)
+	for scanner.Scan() {
+		sketch.Incr(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	if err := writeTopK(out, sketch, *format); err != nil {
+		log.Fatal(err)
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writeTopK writes the sketch's top K items in descending count order (see [topk.Sketch.SortedSlice]) to w in the given format.
+func writeTopK(w io.Writer, sketch *topk.Sketch, format string) error {
+	switch format {
+	case "text":
+		for _, item := range sketch.SortedSlice() {
+			if _, err := fmt.Fprintf(w, "%s : %d\n", item.Item, item.Count); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "json":
+		data, err := sketch.TopKJSON()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(append(data, '\n'))
+		return err
+	case "csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write([]string{"item", "count"}); err != nil {
+			return err
+		}
+		for _, item := range sketch.SortedSlice() {
+			if err := cw.Write([]string{item.Item, strconv.FormatUint(uint64(item.Count), 10)}); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unknown format %q, expected text, json, or csv", format)
+	}
+}
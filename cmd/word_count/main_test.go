@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestWriteTopK(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("the", 5)
+	sketch.Add("a, b", 3)
+	sketch.Add("sketch", 1)
+
+	for _, tc := range []struct {
+		format   string
+		expected string
+	}{
+		{format: "text", expected: "the : 5\na, b : 3\nsketch : 1\n"},
+		{format: "json", expected: `[{"item":"the","count":5},{"item":"a, b","count":3},{"item":"sketch","count":1}]` + "\n"},
+		{format: "csv", expected: "item,count\nthe,5\n\"a, b\",3\nsketch,1\n"},
+	} {
+		t.Run(tc.format, func(t *testing.T) {
+			var out strings.Builder
+			if err := writeTopK(&out, sketch, tc.format); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.expected, out.String()); diff != "" {
+				t.Errorf("Output mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if err := writeTopK(&strings.Builder{}, sketch, "xml"); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	// Every format accepted by the flag validation must be supported by writeTopK.
+	for _, format := range formats {
+		if err := writeTopK(&strings.Builder{}, topk.New(3), format); err != nil {
+			t.Errorf("writeTopK(%q): %v", format, err)
+		}
+	}
+}
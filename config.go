@@ -0,0 +1,47 @@
+package topk
+
+import "slices"
+
+// Config is the configuration of a sketch that determines its geometry and hashing, see [Sketch.Config].
+// Sketches created from the same Config are compatible for merging (see [Sketch.Merge]).
+type Config struct {
+	K            int     // Number of top items to keep.
+	Width        int     // Number of buckets per hash function.
+	Depth        int     // Number of hash functions.
+	RowWidths    []int   // Optional per-row numbers of buckets, see [WithRowWidths].
+	Decay        float32 // Counter decay probability on collisions.
+	DecayLUTSize int     // Size of the decay look-up table.
+	Seed         uint32  // Hash seed, see [WithSeed].
+}
+
+// Config returns the sketch's configuration, e.g. for sharing it between a producer and a consumer of sketches that are merged.
+// Options that don't affect the geometry or the hashing (and the hash functions set by [WithHasher]) are not included.
+func (me *Sketch) Config() Config {
+	return Config{
+		K:            me.K,
+		Width:        me.Width,
+		Depth:        me.Depth,
+		RowWidths:    slices.Clone(me.RowWidths),
+		Decay:        me.Decay,
+		DecayLUTSize: len(me.DecayLUT),
+		Seed:         me.Seed,
+	}
+}
+
+// NewFromConfig returns an empty sketch with the given configuration (see [Sketch.Config]).
+// The options are applied after the configuration.
+func NewFromConfig(config Config, opts ...Option) *Sketch {
+	configOpts := []Option{
+		WithWidth(config.Width),
+		WithDepth(config.Depth),
+		WithDecay(config.Decay),
+		WithSeed(config.Seed),
+	}
+	if config.RowWidths != nil {
+		configOpts = append(configOpts, WithRowWidths(config.RowWidths))
+	}
+	if config.DecayLUTSize > 0 {
+		configOpts = append(configOpts, WithDecayLUTSize(config.DecayLUTSize))
+	}
+	return New(config.K, append(configOpts, opts...)...)
+}
@@ -0,0 +1,32 @@
+package topk_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestNewFromConfig(t *testing.T) {
+	for _, sketch := range []*topk.Sketch{
+		topk.New(5, topk.WithWidth(128), topk.WithDepth(4), topk.WithDecay(0.8), topk.WithDecayLUTSize(64), topk.WithSeed(3)),
+		topk.New(5, topk.WithRowWidths([]int{31, 32, 33})),
+	} {
+		sketch.Add("a", 10)
+		sketch.Add("b", 5)
+
+		fresh := topk.NewFromConfig(sketch.Config())
+		if diff := cmp.Diff(sketch.Config(), fresh.Config()); diff != "" {
+			t.Errorf("Config mismatch (-original +fresh):\n%s", diff)
+		}
+		if len(fresh.SortedSlice()) != 0 || fresh.TotalCount() != 0 {
+			t.Errorf("Expected an empty sketch, got %v", fresh.SortedSlice())
+		}
+		if err := fresh.Merge(sketch); err != nil {
+			t.Fatalf("Expected a compatible sketch, got %v", err)
+		}
+		if diff := cmp.Diff(sketch.SortedSlice(), fresh.SortedSlice()); diff != "" {
+			t.Errorf("Merged top K mismatch (-original +fresh):\n%s", diff)
+		}
+	}
+}
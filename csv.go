@@ -0,0 +1,64 @@
+package topk
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// csvHeader is the header row written by [Sketch.WriteCSV].
+var csvHeader = []string{"item", "count", "fingerprint"}
+
+// WriteCSV writes the top K items in descending count order (see [Sketch.SortedSlice]) to w as CSV,
+// with a header row followed by one `item,count,fingerprint` row per item.
+// Items containing commas, quotes, or line breaks are quoted as needed (see [csv.Writer]).
+func (me *Sketch) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, item := range me.SortedSlice() {
+		row := []string{item.Item, strconv.FormatUint(uint64(item.Count), 10), strconv.FormatUint(uint64(item.Fingerprint), 10)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSVCounts reads the item counts written by [Sketch.WriteCSV]. The header row and the fingerprint column are optional,
+// so that `item,count` rows from other sources are accepted, too.
+// The counts can be used to seed a sketch, e.g. via [Sketch.AddExact].
+//
+// Rows with a missing or non-numeric count, and items that occur more than once, are reported as [ErrInvalidEncoding].
+func ReadCSVCounts(r io.Reader) (map[string]uint32, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	counts := make(map[string]uint32)
+	for record := 1; ; record++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return counts, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if record == 1 && len(row) >= 2 && row[0] == csvHeader[0] && row[1] == csvHeader[1] {
+			continue
+		}
+		if len(row) < 2 {
+			return nil, fmt.Errorf("%w: record %d: expected item and count, got %d fields", ErrInvalidEncoding, record, len(row))
+		}
+		count, err := strconv.ParseUint(row[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: record %d: invalid count %q", ErrInvalidEncoding, record, row[1])
+		}
+		if _, ok := counts[row[0]]; ok {
+			return nil, fmt.Errorf("%w: record %d: duplicate item %q", ErrInvalidEncoding, record, row[0])
+		}
+		counts[row[0]] = uint32(count)
+	}
+}
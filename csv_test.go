@@ -0,0 +1,51 @@
+package topk_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_WriteCSV(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	counts := map[string]uint32{"a": 10, "b,c": 7, `say "hi"`: 5, "multi\nline": 3}
+	for item, count := range counts {
+		sketch.Add(item, count)
+	}
+
+	var buf bytes.Buffer
+	if err := sketch.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "item,count,fingerprint\na,10,") {
+		t.Errorf("Expected a header and the rows in rank order, got %q", buf.String())
+	}
+
+	actual, err := topk.ReadCSVCounts(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(counts, actual); diff != "" {
+		t.Errorf("Round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReadCSVCounts(t *testing.T) {
+	actual, err := topk.ReadCSVCounts(strings.NewReader("x,3\ny,1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(map[string]uint32{"x": 3, "y": 1}, actual); diff != "" {
+		t.Errorf("Counts mismatch (-want +got):\n%s", diff)
+	}
+
+	for _, input := range []string{"x\n", "x,-1\n", "x,abc\n", "x,1\nx,2\n", "item,count\nx,99999999999\n"} {
+		if _, err := topk.ReadCSVCounts(strings.NewReader(input)); !errors.Is(err, topk.ErrInvalidEncoding) {
+			t.Errorf("ReadCSVCounts(%q): expected ErrInvalidEncoding, got %v", input, err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package topk
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/keilerkonzept/topk/internal/bloom"
+)
+
+// ErrInvalidEncoding is returned when decoding malformed sketch data.
+var ErrInvalidEncoding = errors.New("topk: invalid encoding")
+
+const (
+	bucketsOnlyMagic      = "tkb\x02"
+	bucketsOnlyHeaderSize = len(bucketsOnlyMagic) + 7*4
+	encodedBucketSize     = 2 * 4
+
+	// Limits on the decoded K and decay LUT size, which are not backed by encoded data and would otherwise size allocations on their own.
+	maxDecodedK            = 1 << 20
+	maxDecodedDecayLUTSize = 1 << 16
+)
+
+// MarshalBucketsOnly encodes the sketch's parameters and buckets, but not its top-K heap.
+// This is smaller than encoding the whole sketch when K is large, for receivers that maintain their own top K.
+func (me *Sketch) MarshalBucketsOnly() ([]byte, error) {
+	out := make([]byte, 0, bucketsOnlyHeaderSize+4*len(me.RowWidths)+encodedBucketSize*len(me.Buckets))
+	out = append(out, bucketsOnlyMagic...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(me.K))
+	out = binary.LittleEndian.AppendUint32(out, uint32(me.Width))
+	out = binary.LittleEndian.AppendUint32(out, uint32(me.Depth))
+	out = binary.LittleEndian.AppendUint32(out, math.Float32bits(me.Decay))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(me.DecayLUT)))
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(me.RowWidths)))
+	out = binary.LittleEndian.AppendUint32(out, me.Seed)
+	for _, w := range me.RowWidths {
+		out = binary.LittleEndian.AppendUint32(out, uint32(w))
+	}
+	for _, b := range me.Buckets {
+		out = binary.LittleEndian.AppendUint32(out, b.Fingerprint)
+		out = binary.LittleEndian.AppendUint32(out, b.Count)
+	}
+	return out, nil
+}
+
+// UnmarshalBucketsOnly decodes data produced by [Sketch.MarshalBucketsOnly] into the sketch,
+// replacing its parameters and buckets, recomputing the decay LUT, and starting with an empty top-K heap.
+// The total count, the count decrease counter, and the baseline of [Sketch.ChangedSince] start over as well, since they aren't encoded.
+// Data with a K above 2^20 or a decay LUT size above 2^16 is rejected, since neither is backed by the encoded data.
+//
+// The heap is re-populated as items are counted again via [Sketch.Add].
+func (me *Sketch) UnmarshalBucketsOnly(data []byte) error {
+	if len(data) < bucketsOnlyHeaderSize || string(data[:len(bucketsOnlyMagic)]) != bucketsOnlyMagic {
+		return fmt.Errorf("%w: missing buckets-only header", ErrInvalidEncoding)
+	}
+	data = data[len(bucketsOnlyMagic):]
+	next := func() uint32 {
+		v := binary.LittleEndian.Uint32(data)
+		data = data[4:]
+		return v
+	}
+	k, width, depth := int(next()), int(next()), int(next())
+	decay := math.Float32frombits(next())
+	decayLUTSize := int(next())
+	numRowWidths := int(next())
+	seed := next()
+	if k > maxDecodedK {
+		return fmt.Errorf("%w: K %d exceeds the maximum of %d", ErrInvalidEncoding, k, maxDecodedK)
+	}
+	if decayLUTSize < 2 || decayLUTSize > maxDecodedDecayLUTSize {
+		return fmt.Errorf("%w: decay LUT size %d not in [2, %d]", ErrInvalidEncoding, decayLUTSize, maxDecodedDecayLUTSize)
+	}
+	if numRowWidths != 0 && numRowWidths != depth || len(data) < 4*numRowWidths {
+		return fmt.Errorf("%w: got %d row widths for depth %d", ErrInvalidEncoding, numRowWidths, depth)
+	}
+	if numRowWidths == 0 && width == 0 {
+		return fmt.Errorf("%w: zero width", ErrInvalidEncoding)
+	}
+	numBuckets := uint64(width) * uint64(depth)
+	var rowWidths []int
+	if numRowWidths > 0 {
+		rowWidths = make([]int, numRowWidths)
+		numBuckets = 0
+		for i := range rowWidths {
+			if rowWidths[i] = int(next()); rowWidths[i] == 0 {
+				return fmt.Errorf("%w: zero width of row %d", ErrInvalidEncoding, i)
+			}
+			numBuckets += uint64(rowWidths[i])
+		}
+	}
+	if uint64(len(data)) != numBuckets*encodedBucketSize {
+		return fmt.Errorf("%w: expected %d buckets, got %d bytes of bucket data", ErrInvalidEncoding, numBuckets, len(data))
+	}
+
+	me.K, me.Width, me.Depth = k, width, depth
+	me.RowWidths, me.RowOffsets = rowWidths, nil
+	me.Decay = decay
+	me.Seed = seed
+	me.DecayLUT = make([]float32, decayLUTSize)
+	me.initDecayLUT()
+	me.initBuckets()
+	for i := range me.Buckets {
+		me.Buckets[i] = Bucket{Fingerprint: next(), Count: next()}
+	}
+	me.Heap = me.newHeap()
+	me.Total, me.CountDecreases = 0, 0
+	me.snapshot = nil
+	if me.queryFilter != nil {
+		me.queryFilter = bloom.New(me.K)
+		me.queryFilterInserts = 0
+	}
+	return nil
+}
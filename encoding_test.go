@@ -0,0 +1,131 @@
+package topk_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_MarshalBucketsOnly(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(64), topk.WithDepth(3), topk.WithDecay(0.8), topk.WithDecayLUTSize(128), topk.WithSeed(7))
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", i%50), uint32(1+i%7))
+	}
+
+	data, err := sketch.MarshalBucketsOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded topk.Sketch
+	if err := decoded.UnmarshalBucketsOnly(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.K != sketch.K || decoded.Width != sketch.Width || decoded.Depth != sketch.Depth || decoded.Decay != sketch.Decay || decoded.Seed != sketch.Seed {
+		t.Errorf("Expected parameters K=%d Width=%d Depth=%d Decay=%v Seed=%d, got K=%d Width=%d Depth=%d Decay=%v Seed=%d",
+			sketch.K, sketch.Width, sketch.Depth, sketch.Decay, sketch.Seed, decoded.K, decoded.Width, decoded.Depth, decoded.Decay, decoded.Seed)
+	}
+	if diff := cmp.Diff(sketch.DecayLUT, decoded.DecayLUT); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(sketch.Buckets, decoded.Buckets); diff != "" {
+		t.Error(diff)
+	}
+	if len(decoded.SortedSlice()) != 0 {
+		t.Errorf("Expected an empty top-K after a buckets-only load, got %v", decoded.SortedSlice())
+	}
+
+	decoded.Add("item1", 1)
+	if !decoded.Query("item1") {
+		t.Error("Expected the top-K to be re-populated by Add")
+	}
+	if expected, actual := sketch.Count("item1")+1, decoded.Count("item1"); actual > expected {
+		t.Errorf("Expected Count(item1) <= %d, got %d", expected, actual)
+	}
+}
+
+func TestSketch_UnmarshalBucketsOnly_Invalid(t *testing.T) {
+	data, err := topk.New(5, topk.WithWidth(8), topk.WithDepth(2)).MarshalBucketsOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	withUint32 := func(offset int, v uint32) []byte {
+		out := slices.Clone(data)
+		binary.LittleEndian.PutUint32(out[offset:], v)
+		return out
+	}
+	hugeK := withUint32(4, 1<<31)
+	hugeDecayLUT := withUint32(20, 1<<31)
+	emptyDecayLUT := withUint32(20, 0)
+	singleDecayLUT := withUint32(20, 1)
+	// Headers without bucket data, whose zero widths would otherwise match the (empty) bucket data.
+	zeroWidth := binary.LittleEndian.AppendUint32(slices.Clone(data[:8]), 0)
+	zeroWidth = append(zeroWidth, data[12:36]...)
+	zeroRowWidth := binary.LittleEndian.AppendUint32(slices.Clone(data[:12]), 1)
+	zeroRowWidth = append(zeroRowWidth, data[16:24]...)
+	zeroRowWidth = binary.LittleEndian.AppendUint32(zeroRowWidth, 1)
+	zeroRowWidth = append(zeroRowWidth, data[28:36]...)
+	zeroRowWidth = binary.LittleEndian.AppendUint32(zeroRowWidth, 0)
+	// The first draft of the format, without the hash seed, was never released and is not accepted.
+	v1 := append([]byte("tkb\x01"), data[4:]...)
+
+	for _, invalid := range [][]byte{nil, data[:10], data[:len(data)-1], append([]byte("xxxx"), data[4:]...), hugeK, hugeDecayLUT, emptyDecayLUT, singleDecayLUT, zeroWidth, zeroRowWidth, v1} {
+		var decoded topk.Sketch
+		if err := decoded.UnmarshalBucketsOnly(invalid); !errors.Is(err, topk.ErrInvalidEncoding) {
+			t.Errorf("Expected ErrInvalidEncoding, got %v", err)
+		}
+	}
+}
+
+func TestSketch_UnmarshalBucketsOnly_ResetsStats(t *testing.T) {
+	data, err := topk.New(5, topk.WithWidth(8), topk.WithDepth(2)).MarshalBucketsOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sketch := topk.New(5, topk.WithWidth(8), topk.WithDepth(2), topk.WithCountDecreaseDetection())
+	sketch.Add("a", 10)
+	sketch.Snapshot()
+	sketch.CountDecreases = 3
+	if err := sketch.UnmarshalBucketsOnly(data); err != nil {
+		t.Fatal(err)
+	}
+	if sketch.TotalCount() != 0 || sketch.CountDecreases != 0 {
+		t.Errorf("Expected the total count and count decreases to be reset, got %d and %d", sketch.TotalCount(), sketch.CountDecreases)
+	}
+	// The same count and rank as in the old snapshot, which must not serve as the baseline of the decoded sketch.
+	sketch.Add("a", 10)
+	if changed := sketch.ChangedSince(); len(changed) != 1 || changed[0].Item != "a" {
+		t.Errorf("Expected a to be reported as changed after the snapshot was reset, got %v", changed)
+	}
+}
+
+func TestSketch_MarshalBucketsOnly_RowWidths(t *testing.T) {
+	sketch := topk.New(5, topk.WithRowWidths([]int{31, 32, 33}))
+	for i := range 100 {
+		sketch.Add(fmt.Sprintf("item%d", i%20), 1)
+	}
+
+	data, err := sketch.MarshalBucketsOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded topk.Sketch
+	if err := decoded.UnmarshalBucketsOnly(data); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(sketch.RowOffsets, decoded.RowOffsets); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(sketch.Buckets, decoded.Buckets); diff != "" {
+		t.Error(diff)
+	}
+}
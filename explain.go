@@ -0,0 +1,28 @@
+package topk
+
+import "fmt"
+
+// WhyNotTopK returns a human-readable diagnosis of why the item is (or is not) in the top K:
+// its estimated count, how many of its buckets hold its fingerprint, the current cutoff (see [Sketch.CutoffCount]),
+// and how far below the cutoff the item's count is.
+//
+// This is a support tool for the common "my item is popular but missing" complaint; the format of the text may change.
+func (me *Sketch) WhyNotTopK(item string) string {
+	if rank, ok := me.Rank(item); ok {
+		return fmt.Sprintf("%q is in the top %d at rank %d with a count of %d", item, me.K, rank, me.Count(item))
+	}
+	count, rowsMatched := me.CountConfidence(item)
+	cutoff := me.CutoffCount()
+	if rowsMatched == 0 {
+		return fmt.Sprintf("%q has no estimated count: none of its %d buckets hold its fingerprint, "+
+			"so it has not been counted, or other items have taken over its buckets (top-%d cutoff: %d)",
+			item, me.Depth, me.K, cutoff)
+	}
+	if count < cutoff {
+		return fmt.Sprintf("%q has an estimated count of %d (from %d of %d buckets), %d below the top-%d cutoff of %d",
+			item, count, rowsMatched, me.Depth, cutoff-count, me.K, cutoff)
+	}
+	return fmt.Sprintf("%q has an estimated count of %d (from %d of %d buckets), which reaches the top-%d cutoff of %d, "+
+		"but it is not in the heap: it was removed (e.g. by EvictIdle or Decr) and re-enters the top K on its next Add",
+		item, count, rowsMatched, me.Depth, me.K, cutoff)
+}
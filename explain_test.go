@@ -0,0 +1,24 @@
+package topk_test
+
+import (
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_WhyNotTopK(t *testing.T) {
+	sketch := topk.New(2, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 50)
+	sketch.Add("b", 20)
+	sketch.Add("c", 5)
+
+	for item, expected := range map[string]string{
+		"a": `"a" is in the top 2 at rank 1 with a count of 50`,
+		"c": `"c" has an estimated count of 5 (from 3 of 3 buckets), 15 below the top-2 cutoff of 20`,
+		"x": `"x" has no estimated count: none of its 3 buckets hold its fingerprint, so it has not been counted, or other items have taken over its buckets (top-2 cutoff: 20)`,
+	} {
+		if actual := sketch.WhyNotTopK(item); actual != expected {
+			t.Errorf("Expected WhyNotTopK(%s) = %s, got %s", item, expected, actual)
+		}
+	}
+}
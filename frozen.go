@@ -0,0 +1,152 @@
+package topk
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// ErrReadOnly is returned by the mutating methods of read-only sketches such as [Frozen].
+var ErrReadOnly = errors.New("topk: sketch is read-only")
+
+// Frozen is an immutable, read-optimized snapshot of a [Sketch], created by [Sketch.Freeze].
+//
+// It keeps the buckets for estimating the counts of items outside the top K,
+// but drops the write-side machinery (decay LUT, heap index map):
+// the top K items are stored pre-sorted, and membership is resolved by binary search over the sorted item strings in O(log K).
+type Frozen struct {
+	K     int // Number of top items tracked by the source sketch.
+	Width int // Number of buckets per hash function (the largest row width if RowWidths is set).
+	Depth int // Number of hash functions.
+
+	RowWidths  []int // Per-row numbers of buckets, nil if every row has Width buckets.
+	RowOffsets []int // RowOffsets[i] is the index in Buckets of row i's first bucket.
+
+	Seed uint32 // Hash seed of the source sketch, see [WithSeed].
+
+	Items    []heap.Item // Top-K items in descending count order (as returned by [Sketch.SortedSlice]).
+	Keys     []string    // Item strings of Items, in lexicographic order.
+	KeyRanks []int       // KeyRanks[i] is the index in Items of Keys[i].
+
+	Buckets []Bucket // Sketch counters.
+
+	fingerprintFunc func(item string) uint32              // Fingerprint hash of the source sketch, see [WithHasher].
+	bucketIndexFunc func(item string, row, width int) int // Bucket index hash of the source sketch, see [WithHasher].
+}
+
+// Freeze returns an immutable snapshot of the sketch that shares no memory with it.
+func (me *Sketch) Freeze() *Frozen {
+	items := me.SortedSlice()
+	ranks := make([]int, len(items))
+	for i := range ranks {
+		ranks[i] = i
+	}
+	slices.SortFunc(ranks, func(i, j int) int {
+		return strings.Compare(items[i].Item, items[j].Item)
+	})
+	keys := make([]string, len(items))
+	for i, rank := range ranks {
+		keys[i] = items[rank].Item
+	}
+
+	return &Frozen{
+		K:          me.K,
+		Width:      me.Width,
+		Depth:      me.Depth,
+		RowWidths:  slices.Clone(me.RowWidths),
+		RowOffsets: slices.Clone(me.RowOffsets),
+		Seed:       me.Seed,
+		Items:      items,
+		Keys:       keys,
+		KeyRanks:   ranks,
+		Buckets:    slices.Clone(me.Buckets),
+
+		fingerprintFunc: me.fingerprintFunc,
+		bucketIndexFunc: me.bucketIndexFunc,
+	}
+}
+
+// find returns the index of the item in Items, or -1 if it is not in the top K.
+func (me *Frozen) find(item string) int {
+	if i, ok := slices.BinarySearch(me.Keys, item); ok {
+		return me.KeyRanks[i]
+	}
+	return -1
+}
+
+// Query returns whether the given item is in the top K items by count.
+func (me *Frozen) Query(item string) bool {
+	return me.find(item) >= 0
+}
+
+// Count returns the estimated count of the given item.
+func (me *Frozen) Count(item string) uint32 {
+	if i := me.find(item); i >= 0 {
+		return me.Items[i].Count
+	}
+
+	fingerprint := fingerprintWith(me.fingerprintFunc, me.Seed, item)
+	var maxCount uint32
+
+	for i := range me.Depth {
+		b := &me.Buckets[bucketIndexWith(me.bucketIndexFunc, me.Seed, item, i, me.Width, me.RowWidths, me.RowOffsets)]
+		if b.Fingerprint != fingerprint {
+			continue
+		}
+		maxCount = max(maxCount, b.Count)
+	}
+
+	return maxCount
+}
+
+// Add fails with [ErrReadOnly], since a frozen snapshot can't count items. Count into the source sketch and freeze it again instead.
+func (me *Frozen) Add(item string, increment uint32) error {
+	return fmt.Errorf("%w: can't add %q to a frozen snapshot", ErrReadOnly, item)
+}
+
+// Incr fails with [ErrReadOnly], like [Frozen.Add].
+func (me *Frozen) Incr(item string) error {
+	return me.Add(item, 1)
+}
+
+// Reset fails with [ErrReadOnly], since a frozen snapshot can't be modified.
+func (me *Frozen) Reset() error {
+	return fmt.Errorf("%w: can't reset a frozen snapshot", ErrReadOnly)
+}
+
+// Rank returns the 1-based position of the item in the top K in descending count order (as in [Sketch.Rank]),
+// and whether the item is in the top K. It finds the item by binary search in O(log K) time.
+func (me *Frozen) Rank(item string) (rank int, ok bool) {
+	if i := me.find(item); i >= 0 {
+		return i + 1, true
+	}
+	return 0, false
+}
+
+// KeyIndex returns a map from each of the top K items to its 1-based rank (see [Frozen.Rank]),
+// for embedding the top K in a lookup table that is used without the sketch.
+// The map is newly allocated and not retained by the snapshot.
+func (me *Frozen) KeyIndex() map[string]int {
+	index := make(map[string]int, len(me.Items))
+	for i, item := range me.Items {
+		index[item.Item] = i + 1
+	}
+	return index
+}
+
+// Iter iterates over the top K items in descending count order.
+func (me *Frozen) Iter(yield func(*heap.Item) bool) {
+	for i := range me.Items {
+		if !yield(&me.Items[i]) {
+			break
+		}
+	}
+}
+
+// SortedSlice returns the top K items as a sorted slice.
+func (me *Frozen) SortedSlice() []heap.Item {
+	return slices.Clone(me.Items)
+}
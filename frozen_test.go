@@ -0,0 +1,97 @@
+package topk_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_Freeze(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(64), topk.WithDepth(3))
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", i%100), uint32(1+i%13))
+	}
+
+	frozen := sketch.Freeze()
+
+	if diff := cmp.Diff(sketch.SortedSlice(), frozen.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+	for i := range 200 {
+		item := fmt.Sprintf("item%d", i)
+		if expected, actual := sketch.Query(item), frozen.Query(item); expected != actual {
+			t.Errorf("Query(%s): expected %v, got %v", item, expected, actual)
+		}
+		if expected, actual := sketch.Count(item), frozen.Count(item); expected != actual {
+			t.Errorf("Count(%s): expected %d, got %d", item, expected, actual)
+		}
+	}
+
+	var iterated []string
+	for item := range frozen.Iter {
+		iterated = append(iterated, item.Item)
+	}
+	var expected []string
+	for _, item := range sketch.SortedSlice() {
+		expected = append(expected, item.Item)
+	}
+	if diff := cmp.Diff(expected, iterated); diff != "" {
+		t.Error(diff)
+	}
+
+	// the frozen sketch doesn't change with its source
+	before := frozen.SortedSlice()
+	sketch.Add("new", 1000)
+	if frozen.Query("new") {
+		t.Error("Expected the frozen sketch to be unaffected by later adds")
+	}
+	if diff := cmp.Diff(before, frozen.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestFrozen_RankAndKeyIndex(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(64), topk.WithDepth(3))
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", i%100), uint32(1+i%13))
+	}
+	frozen := sketch.Freeze()
+
+	index := frozen.KeyIndex()
+	if len(index) != len(frozen.Items) {
+		t.Errorf("Expected %d keys in the index, got %d", len(frozen.Items), len(index))
+	}
+	for i := range 200 {
+		item := fmt.Sprintf("item%d", i)
+		expectedRank, expectedOK := sketch.Rank(item)
+		rank, ok := frozen.Rank(item)
+		if rank != expectedRank || ok != expectedOK {
+			t.Errorf("Rank(%s): expected (%d, %v), got (%d, %v)", item, expectedRank, expectedOK, rank, ok)
+		}
+		if indexRank, inIndex := index[item]; indexRank != expectedRank || inIndex != expectedOK {
+			t.Errorf("KeyIndex()[%s]: expected (%d, %v), got (%d, %v)", item, expectedRank, expectedOK, indexRank, inIndex)
+		}
+	}
+}
+
+func TestFrozen_ReadOnly(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(64), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 5)
+	frozen := sketch.Freeze()
+
+	for name, err := range map[string]error{
+		"Add":   frozen.Add("b", 1),
+		"Incr":  frozen.Incr("a"),
+		"Reset": frozen.Reset(),
+	} {
+		if !errors.Is(err, topk.ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+	if frozen.Query("b") || frozen.Count("a") != 5 {
+		t.Error("Expected the frozen snapshot to be unchanged")
+	}
+}
@@ -0,0 +1,167 @@
+package topk
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// Generic is a top-k sketch like [Sketch], but for items of any comparable type T (e.g. integer IDs),
+// hashed by a caller-supplied function instead of converting them to strings.
+//
+// The hash function's value is used as the item's fingerprint, and the bucket indices are derived from it;
+// items with equal hashes are therefore counted as one item, so the hash should be well-distributed over all 32 bits.
+//
+// Generic is a separate type rather than the implementation behind [Sketch] (with the string API as a wrapper over it),
+// so that the string-keyed sketch keeps its serializable exported fields and its current performance.
+// It re-uses the string sketch's buckets and decay, but only implements the core of its counting semantics:
+// it supports the options of [New] that shape the buckets and decay, the increment clamp of [WithMaxIncrementPerAdd], and [Generic.TotalCount],
+// and [NewGeneric] rejects the options that would change the counts or the top K in ways Generic doesn't implement.
+type Generic[T comparable] struct {
+	sketch Sketch // Buckets, decay, increment clamp, and total count; its heap is unused.
+	hash   func(T) uint32
+	Heap   *heap.MinOf[T] // Top-K min-heap.
+}
+
+// NewGeneric returns a top-k sketch for items of type T with the given `k` (number of top items to keep) and hash function.
+// It accepts the same options as [New], with the same defaults; options that only affect string items (such as [WithIgnoreEmptyKeys]) have no effect.
+// Panics if any of the options [WithRowWidths], [WithSampling], [WithSeed], [WithHasher], [WithColdStartDecay],
+// [WithMinCount], [WithTiePolicy], or [WithHeapBucketSync] is set, which are not supported,
+// or any of [WithLastUpdateTracking], [WithDecayEventTracking], [WithLifetimeCounts], [WithOnPromote], or [WithOnEvict],
+// which track the string heap's items and have no counterpart in [heap.MinOf].
+func NewGeneric[T comparable](k int, hash func(T) uint32, opts ...Option) *Generic[T] {
+	sketch := New(k, opts...)
+	if unsupported := sketch.unsupportedGenericOptions(); len(unsupported) > 0 {
+		panic(fmt.Sprintf("topk: NewGeneric: unsupported options: %s", strings.Join(unsupported, ", ")))
+	}
+	sketch.Heap = nil
+	return &Generic[T]{
+		sketch: *sketch,
+		hash:   hash,
+		Heap:   heap.NewMinOf[T](k),
+	}
+}
+
+// unsupportedGenericOptions returns the names of the options set on the sketch that [NewGeneric] doesn't support.
+func (me *Sketch) unsupportedGenericOptions() []string {
+	var out []string
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithRowWidths", me.RowWidths != nil},
+		{"WithSampling", me.SamplingRate > 0 && me.SamplingRate < 1},
+		{"WithSeed", me.Seed != 0},
+		{"WithHasher", me.fingerprintFunc != nil || me.bucketIndexFunc != nil},
+		{"WithColdStartDecay", me.ColdStartThreshold != 0},
+		{"WithMinCount", me.MinCount != 0},
+		{"WithTiePolicy", me.TiePolicy != heap.TieReplace},
+		{"WithHeapBucketSync", me.HeapBucketSync},
+		{"WithLastUpdateTracking", me.TrackLastUpdate},
+		{"WithDecayEventTracking", me.TrackDecayEvents},
+		{"WithLifetimeCounts", me.TrackLifetimeCounts},
+		{"WithOnPromote", me.onPromote != nil},
+		{"WithOnEvict", me.onEvict != nil},
+	} {
+		if o.set {
+			out = append(out, o.name)
+		}
+	}
+	return out
+}
+
+// bucketIndex returns the index of the item's bucket in the given row, mixing the row into the item's hash.
+func (me *Generic[T]) bucketIndex(hash uint32, row int) int {
+	x := uint64(hash) ^ (uint64(row)+1)*0x9e3779b97f4a7c15
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return row*me.sketch.Width + int(x%uint64(me.sketch.Width))
+}
+
+// Incr counts a single instance of the given item.
+func (me *Generic[T]) Incr(item T) bool {
+	return me.Add(item, 1)
+}
+
+// Add increments the given item's count by the given increment.
+// Returns whether the item is in the top K.
+func (me *Generic[T]) Add(item T, increment uint32) bool {
+	increment = me.sketch.clampIncrement(increment)
+	me.sketch.Total += uint64(increment)
+	var maxCount uint32
+	fingerprint := me.hash(item)
+
+	for i := range me.sketch.Depth {
+		b := &me.sketch.Buckets[me.bucketIndex(fingerprint, i)]
+		switch {
+		case b.Count == 0:
+			b.Fingerprint = fingerprint
+			b.Count = increment
+			maxCount = max(maxCount, b.Count)
+		case b.Fingerprint == fingerprint:
+			b.Count += increment
+			maxCount = max(maxCount, b.Count)
+		default:
+			maxCount = max(maxCount, me.sketch.decayBucket(b, fingerprint, increment))
+		}
+	}
+
+	return me.Heap.Update(item, fingerprint, maxCount)
+}
+
+// Count returns the estimated count of the given item.
+func (me *Generic[T]) Count(item T) uint32 {
+	if i := me.Heap.Find(item); i >= 0 {
+		return me.Heap.Items[i].Count
+	}
+
+	fingerprint := me.hash(item)
+	var maxCount uint32
+	for i := range me.sketch.Depth {
+		b := &me.sketch.Buckets[me.bucketIndex(fingerprint, i)]
+		if b.Fingerprint == fingerprint {
+			maxCount = max(maxCount, b.Count)
+		}
+	}
+	return maxCount
+}
+
+// Query returns whether the given item is in the top K items by count.
+func (me *Generic[T]) Query(item T) bool {
+	return me.Heap.Contains(item)
+}
+
+// SortedSlice returns the top K items as a slice sorted by descending count (and by fingerprint for equal counts).
+func (me *Generic[T]) SortedSlice() []heap.ItemOf[T] {
+	out := make([]heap.ItemOf[T], 0, len(me.Heap.Items))
+	for _, item := range me.Heap.Items {
+		if item.Count > 0 {
+			out = append(out, item)
+		}
+	}
+	slices.SortFunc(out, func(a, b heap.ItemOf[T]) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Fingerprint, b.Fingerprint)
+	})
+	return out
+}
+
+// TotalCount returns the sum of all increments counted since the sketch was created or last reset, see [Sketch.TotalCount].
+func (me *Generic[T]) TotalCount() uint64 {
+	return me.sketch.Total
+}
+
+// Reset resets the sketch to an empty state.
+func (me *Generic[T]) Reset() {
+	clear(me.sketch.Buckets)
+	me.sketch.Total = 0
+	me.Heap.Reset()
+}
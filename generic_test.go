@@ -0,0 +1,103 @@
+package topk_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/OneOfOne/xxhash"
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func hashUint64(x uint64) uint32 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return xxhash.Checksum32(b[:])
+}
+
+func TestGeneric(t *testing.T) {
+	sketch := topk.NewGeneric(3, hashUint64, topk.WithWidth(1024), topk.WithDepth(3))
+
+	for id := range uint64(100) {
+		sketch.Add(id, 1)
+	}
+	sketch.Add(7, 50)
+	sketch.Add(42, 40)
+	sketch.Add(99, 30)
+	sketch.Incr(99)
+
+	expected := []heap.ItemOf[uint64]{
+		{Fingerprint: hashUint64(7), Item: 7, Count: 51},
+		{Fingerprint: hashUint64(42), Item: 42, Count: 41},
+		{Fingerprint: hashUint64(99), Item: 99, Count: 32},
+	}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("Top K mismatch (-want +got):\n%s", diff)
+	}
+	for _, item := range expected {
+		if !sketch.Query(item.Item) {
+			t.Errorf("Expected %d to be in the top K", item.Item)
+		}
+		if c := sketch.Count(item.Item); c != item.Count {
+			t.Errorf("Expected Count(%d) = %d, got %d", item.Item, item.Count, c)
+		}
+	}
+	if sketch.Query(1) {
+		t.Error("Expected 1 not to be in the top K")
+	}
+	if c := sketch.Count(1); c != 1 {
+		t.Errorf("Expected Count(1) = 1, got %d", c)
+	}
+
+	sketch.Reset()
+	if n := len(sketch.SortedSlice()); n != 0 {
+		t.Errorf("Expected an empty top K after Reset, got %d items", n)
+	}
+}
+
+func TestGeneric_MaxIncrementPerAddAndTotal(t *testing.T) {
+	sketch := topk.NewGeneric(2, hashUint64, topk.WithWidth(1024), topk.WithDepth(3), topk.WithMaxIncrementPerAdd(10))
+	sketch.Add(1, 100)
+	sketch.Incr(2)
+	if c := sketch.Count(1); c != 10 {
+		t.Errorf("Expected the increment to be clamped to 10, got Count(1) = %d", c)
+	}
+	if total := sketch.TotalCount(); total != 11 {
+		t.Errorf("Expected a total count of 11, got %d", total)
+	}
+	sketch.Reset()
+	if total := sketch.TotalCount(); total != 0 {
+		t.Errorf("Expected a total count of 0 after Reset, got %d", total)
+	}
+}
+
+func TestNewGeneric_UnsupportedOptions(t *testing.T) {
+	for name, opt := range map[string]topk.Option{
+		"WithRowWidths":          topk.WithRowWidths([]int{8, 16}),
+		"WithSampling":           topk.WithSampling(0.5),
+		"WithSeed":               topk.WithSeed(1),
+		"WithHasher":             topk.WithHasher(topk.Fingerprint, topk.BucketIndex),
+		"WithColdStartDecay":     topk.WithColdStartDecay(5),
+		"WithMinCount":           topk.WithMinCount(5),
+		"WithTiePolicy":          topk.WithTiePolicy(heap.TieReject),
+		"WithHeapBucketSync":     topk.WithHeapBucketSync(),
+		"WithLastUpdateTracking": topk.WithLastUpdateTracking(),
+		"WithDecayEventTracking": topk.WithDecayEventTracking(),
+		"WithLifetimeCounts":     topk.WithLifetimeCounts(),
+		"WithOnPromote":          topk.WithOnPromote(func(string, uint32) {}),
+		"WithOnEvict":            topk.WithOnEvict(func(string, uint32) {}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), name) {
+					t.Errorf("Expected NewGeneric to panic naming %s, got %v", name, r)
+				}
+			}()
+			topk.NewGeneric(2, hashUint64, opt)
+		})
+	}
+}
@@ -6,11 +6,72 @@ const hashSeed = 4848280
 
 // Fingerprint returns an item's fingerprint.
 func Fingerprint(item string) uint32 {
-	return xxhash.ChecksumString32S(item, hashSeed)
+	return FingerprintSeeded(item, 0)
+}
+
+// FingerprintSeeded returns an item's fingerprint in a sketch with the given hash seed (see [WithSeed]).
+func FingerprintSeeded(item string, seed uint32) uint32 {
+	return xxhash.ChecksumString32S(item, hashSeed^mixSeed(seed))
 }
 
 // BucketIndex returns the counter bucket index for an item in the given row of the sketch.
 func BucketIndex(item string, row, width int) int {
-	column := int(xxhash.ChecksumString32S(item, uint32(row))) % width
+	return BucketIndexSeeded(item, row, width, 0)
+}
+
+// BucketIndexSeeded returns the counter bucket index for an item in the given row of a sketch with the given hash seed (see [WithSeed]).
+func BucketIndexSeeded(item string, row, width int, seed uint32) int {
+	column := int(xxhash.ChecksumString32S(item, uint32(row)^mixSeed(seed))) % width
 	return row*width + column
 }
+
+// RowBucketIndex returns the counter bucket index for an item in the given row of a sketch with per-row widths (see [WithRowWidths]),
+// where offsets[row] is the index of the row's first bucket.
+func RowBucketIndex(item string, row int, widths, offsets []int) int {
+	return rowBucketIndexSeeded(item, row, widths, offsets, 0)
+}
+
+func rowBucketIndexSeeded(item string, row int, widths, offsets []int, seed uint32) int {
+	column := int(xxhash.ChecksumString32S(item, uint32(row)^mixSeed(seed))) % widths[row]
+	return offsets[row] + column
+}
+
+// mixSeed spreads a sketch's hash seed over all 32 bits, so that the row seeds of sketches with nearby seeds don't overlap.
+// A seed of 0 is mapped to 0, which keeps the hashes of unseeded sketches unchanged.
+func mixSeed(seed uint32) uint32 {
+	return seed * 0x9e3779b9
+}
+
+// FingerprintDistribution returns a histogram of `Fingerprint(item) % buckets` over the given sample of items.
+// Comparing the histogram against a uniform one shows whether the fingerprint hash distributes well over the given keys.
+func FingerprintDistribution(items []string, buckets int) []int {
+	out := make([]int, buckets)
+	for _, item := range items {
+		out[Fingerprint(item)%uint32(buckets)]++
+	}
+	return out
+}
+
+// fingerprintWith returns the item's fingerprint computed by the given hash function, or by [FingerprintSeeded] if it is nil.
+func fingerprintWith(fingerprint func(item string) uint32, seed uint32, item string) uint32 {
+	if fingerprint == nil {
+		return FingerprintSeeded(item, seed)
+	}
+	return fingerprint(item)
+}
+
+// bucketIndexWith returns the index of the item's bucket in the given row, computed by the given bucket hash function,
+// or by [BucketIndexSeeded] if it is nil. If widths is non-nil, the rows have per-row widths starting at the given offsets.
+func bucketIndexWith(bucketIndex func(item string, row, width int) int, seed uint32, item string, row, width int, widths, offsets []int) int {
+	switch {
+	case bucketIndex == nil && widths == nil:
+		return BucketIndexSeeded(item, row, width, seed)
+	case bucketIndex == nil:
+		return rowBucketIndexSeeded(item, row, widths, offsets, seed)
+	case widths == nil:
+		return bucketIndex(item, row, width)
+	default:
+		w := widths[row]
+		return offsets[row] + bucketIndex(item, row, w) - row*w
+	}
+}
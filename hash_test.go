@@ -0,0 +1,71 @@
+package topk_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestFingerprintDistribution(t *testing.T) {
+	const buckets = 16
+	const n = 16_000
+
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf("key-%x", rand.Uint64())
+	}
+	hist := topk.FingerprintDistribution(items, buckets)
+	total := 0
+	for i, c := range hist {
+		total += c
+		if c < n/buckets*8/10 || c > n/buckets*12/10 {
+			t.Errorf("Expected bucket %d to hold about %d items, got %d", i, n/buckets, c)
+		}
+	}
+	if total != n {
+		t.Errorf("Expected %d items in the histogram, got %d", n, total)
+	}
+
+	// Keys picked to share a fingerprint residue are detectably skewed.
+	var skewed []string
+	for i := 0; len(skewed) < 1000; i++ {
+		item := fmt.Sprintf("key-%d", i)
+		if topk.Fingerprint(item)%buckets == 3 {
+			skewed = append(skewed, item)
+		}
+	}
+	hist = topk.FingerprintDistribution(skewed, buckets)
+	if hist[3] != len(skewed) {
+		t.Errorf("Expected all %d skewed items in bucket 3, got %v", len(skewed), hist)
+	}
+}
+
+func TestBucketIndexSeeded(t *testing.T) {
+	const width = 64
+	items := make([]string, 1000)
+	for i := range items {
+		items[i] = fmt.Sprintf("item%d", i)
+	}
+
+	same, differentColumns := 0, 0
+	for _, item := range items {
+		if topk.FingerprintSeeded(item, 0) != topk.Fingerprint(item) || topk.BucketIndexSeeded(item, 1, width, 0) != topk.BucketIndex(item, 1, width) {
+			t.Fatalf("Expected seed 0 to give the unseeded hashes for %q", item)
+		}
+		if topk.FingerprintSeeded(item, 1) == topk.FingerprintSeeded(item, 2) {
+			same++
+		}
+		if topk.BucketIndexSeeded(item, 1, width, 1) != topk.BucketIndexSeeded(item, 1, width, 2) {
+			differentColumns++
+		}
+	}
+	if same != 0 {
+		t.Errorf("Expected different fingerprints for different seeds, got %d equal ones", same)
+	}
+	// Independent columns coincide for about 1/width of the items.
+	if differentColumns < len(items)*9/10 {
+		t.Errorf("Expected most items in different columns for different seeds, got %d of %d", differentColumns, len(items))
+	}
+}
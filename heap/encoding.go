@@ -0,0 +1,87 @@
+package heap
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidEncoding is returned when decoding malformed heap data.
+var ErrInvalidEncoding = errors.New("heap: invalid encoding")
+
+const (
+	encodedHeaderSize     = 4 + 4     // K, reserve
+	encodedItemHeaderSize = 4 + 4 + 4 // fingerprint, count, item length
+)
+
+// EncodedSizeBytes returns the length of the encoding of the heap by [Min.MarshalBinary]:
+// 8 bytes for K and the reserve, and for each item 12 bytes plus the length of its item string.
+func (me *Min) EncodedSizeBytes() int {
+	return encodedHeaderSize + len(me.Items)*encodedItemHeaderSize + me.StoredKeysBytes
+}
+
+// MarshalBinary implements [encoding.BinaryMarshaler]. It encodes K and the reserve, followed by each item's fingerprint, count,
+// and length-prefixed item string, as little-endian uint32s. The item order is the heap order.
+//
+// The other fields (the tie policy, minimum count, and callbacks) and the items' tracking fields (e.g. [Item.LastUpdateUnixNano]) are not encoded.
+func (me *Min) MarshalBinary() ([]byte, error) {
+	out := make([]byte, 0, me.EncodedSizeBytes())
+	out = binary.LittleEndian.AppendUint32(out, uint32(me.K))
+	out = binary.LittleEndian.AppendUint32(out, uint32(me.Reserve))
+	for _, item := range me.Items {
+		out = binary.LittleEndian.AppendUint32(out, item.Fingerprint)
+		out = binary.LittleEndian.AppendUint32(out, item.Count)
+		out = binary.LittleEndian.AppendUint32(out, uint32(len(item.Item)))
+		out = append(out, item.Item...)
+	}
+	return out, nil
+}
+
+// UnmarshalBinary implements [encoding.BinaryUnmarshaler]. It decodes data produced by [Min.MarshalBinary] into the heap,
+// replacing its K, reserve, and items, rebuilding the index, and restoring the heap order.
+// Data with more than K plus the reserve items is rejected. The heap's other fields are kept.
+func (me *Min) UnmarshalBinary(data []byte) error {
+	if len(data) < encodedHeaderSize {
+		return fmt.Errorf("%w: missing header", ErrInvalidEncoding)
+	}
+	k := int(binary.LittleEndian.Uint32(data))
+	reserve := int(binary.LittleEndian.Uint32(data[4:]))
+	data = data[encodedHeaderSize:]
+
+	// K and the reserve are untrusted, so the allocations are sized by the items actually present, which are bounded by the data length.
+	items := make([]Item, 0, min(k+reserve, len(data)/encodedItemHeaderSize))
+	index := make(map[string]int, cap(items))
+	storedKeysBytes := 0
+	for len(data) > 0 {
+		if len(items) == k+reserve {
+			return fmt.Errorf("%w: more than K + reserve = %d + %d items", ErrInvalidEncoding, k, reserve)
+		}
+		if len(data) < encodedItemHeaderSize {
+			return fmt.Errorf("%w: item %d: truncated header", ErrInvalidEncoding, len(items))
+		}
+		fingerprint := binary.LittleEndian.Uint32(data)
+		count := binary.LittleEndian.Uint32(data[4:])
+		n := binary.LittleEndian.Uint32(data[8:])
+		data = data[encodedItemHeaderSize:]
+		if uint64(len(data)) < uint64(n) {
+			return fmt.Errorf("%w: item %d: expected %d bytes, got %d", ErrInvalidEncoding, len(items), n, len(data))
+		}
+		item := string(data[:n])
+		data = data[n:]
+		if _, ok := index[item]; ok {
+			return fmt.Errorf("%w: item %d: duplicate item %q", ErrInvalidEncoding, len(items), item)
+		}
+		index[item] = len(items)
+		items = append(items, Item{Fingerprint: fingerprint, Item: item, Count: count})
+		storedKeysBytes += len(item)
+	}
+
+	me.K = k
+	me.Reserve = reserve
+	me.Items = items
+	me.Index = index
+	me.StoredKeysBytes = storedKeysBytes
+	heap.Init(me)
+	return nil
+}
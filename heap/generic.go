@@ -0,0 +1,133 @@
+package heap
+
+import "container/heap"
+
+// ItemOf is an entry in the MinOf-heap with a fingerprint, the item key, and its count.
+type ItemOf[T comparable] struct {
+	Fingerprint uint32
+	Item        T
+	Count       uint32
+}
+
+// MinOf is a min-heap like [Min], but keyed by items of any comparable type.
+// Since such items can't be ordered, items with equal counts are ordered by fingerprint instead of by item.
+type MinOf[T comparable] struct {
+	K     int
+	Items []ItemOf[T]
+	Index map[T]int
+}
+
+// NewMinOf creates and returns a new MinOf-heap with a capacity of up to k items.
+func NewMinOf[T comparable](k int) *MinOf[T] {
+	return &MinOf[T]{
+		K:     k,
+		Items: make([]ItemOf[T], 0, k),
+		Index: make(map[T]int, k),
+	}
+}
+
+// Full checks if the heap is full.
+func (me MinOf[T]) Full() bool { return len(me.Items) == me.K }
+
+// Len returns the number of items currently in the heap. It implements the [heap.Interface].
+func (me MinOf[T]) Len() int { return len(me.Items) }
+
+// Less compares two items in the heap based on their counts (or their fingerprints if counts are equal).
+// It implements the [heap.Interface].
+func (me MinOf[T]) Less(i, j int) bool {
+	ic := me.Items[i].Count
+	jc := me.Items[j].Count
+	if ic == jc {
+		return me.Items[i].Fingerprint < me.Items[j].Fingerprint
+	}
+	return ic < jc
+}
+
+// Swap exchanges two items in the heap and updates their indices in the index map.
+// It implements the [heap.Interface].
+func (me MinOf[T]) Swap(i, j int) {
+	itemi := me.Items[i].Item
+	itemj := me.Items[j].Item
+	me.Items[i], me.Items[j] = me.Items[j], me.Items[i]
+	me.Index[itemi] = j
+	me.Index[itemj] = i
+}
+
+// Push adds a new item to the heap. It implements the [heap.Interface].
+func (me *MinOf[T]) Push(x interface{}) {
+	b := x.(ItemOf[T])
+	me.Items = append(me.Items, b)
+	me.Index[b.Item] = len(me.Items) - 1
+}
+
+// Pop removes and returns the minimum item from the heap. It implements the [heap.Interface].
+func (me *MinOf[T]) Pop() interface{} {
+	old := me.Items
+	n := len(old)
+	x := old[n-1]
+	me.Items = old[0 : n-1]
+	delete(me.Index, x.Item)
+	return x
+}
+
+// Min returns the minimum count in the heap or 0 if the heap is empty.
+func (me MinOf[T]) Min() uint32 {
+	if len(me.Items) == 0 {
+		return 0
+	}
+	return me.Items[0].Count
+}
+
+// Find returns the index of the item in the heap, or -1 if it is not in the heap.
+func (me MinOf[T]) Find(item T) (i int) {
+	if i, ok := me.Index[item]; ok {
+		return i
+	}
+	return -1
+}
+
+// Contains checks if a given item exists in the heap.
+func (me MinOf[T]) Contains(item T) bool {
+	_, ok := me.Index[item]
+	return ok
+}
+
+// Update inserts or updates an item in the heap, like [Min.Update].
+func (me *MinOf[T]) Update(item T, fingerprint uint32, count uint32) bool {
+	if count < me.Min() && me.Full() { // not in top k: ignore
+		return false
+	}
+
+	if i := me.Find(item); i >= 0 { // already in heap: update count
+		me.Items[i].Count = count
+		heap.Fix(me, i)
+		return true
+	}
+
+	if !me.Full() { // heap not full: add to heap
+		heap.Push(me, ItemOf[T]{
+			Count:       count,
+			Fingerprint: fingerprint,
+			Item:        item,
+		})
+		return true
+	}
+
+	// replace min on heap
+	delete(me.Index, me.Items[0].Item)
+	me.Items[0] = ItemOf[T]{
+		Count:       count,
+		Fingerprint: fingerprint,
+		Item:        item,
+	}
+	me.Index[item] = 0
+	heap.Fix(me, 0)
+	return true
+}
+
+// Reset resets the heap.
+func (me *MinOf[T]) Reset() {
+	clear(me.Items)
+	clear(me.Index)
+	me.Items = me.Items[:0]
+}
@@ -0,0 +1,47 @@
+package heap
+
+import (
+	"bytes"
+	"container/heap"
+	"encoding/gob"
+	"fmt"
+)
+
+// gobMin has the fields of [Min], but not its methods, so that it is encoded by gob's default struct encoding.
+type gobMin Min
+
+// GobEncode implements [gob.GobEncoder]. It encodes all exported fields, including the items' tracking fields.
+// Without it, gob would use the more compact [Min.MarshalBinary], which omits them.
+func (me *Min) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*gobMin)(me)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements [gob.GobDecoder]. It rebuilds the index and StoredKeysBytes from the items and restores the heap order.
+// The callbacks are kept from the receiver.
+// Returns an error wrapping [ErrInvalidEncoding] if the items are duplicated or exceed K plus the reserve.
+func (me *Min) GobDecode(data []byte) error {
+	var decoded gobMin
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+	if decoded.K < 0 || decoded.Reserve < 0 || len(decoded.Items) > decoded.K+decoded.Reserve {
+		return fmt.Errorf("%w: %d items for K = %d (reserve %d)", ErrInvalidEncoding, len(decoded.Items), decoded.K, decoded.Reserve)
+	}
+	decoded.Index = make(map[string]int, len(decoded.Items))
+	decoded.StoredKeysBytes = 0
+	for i, item := range decoded.Items {
+		if _, ok := decoded.Index[item.Item]; ok {
+			return fmt.Errorf("%w: item %d: duplicate item %q", ErrInvalidEncoding, i, item.Item)
+		}
+		decoded.Index[item.Item] = i
+		decoded.StoredKeysBytes += len(item.Item)
+	}
+	decoded.OnPromote, decoded.OnEvict = me.OnPromote, me.OnEvict
+	*me = Min(decoded)
+	heap.Init(me)
+	return nil
+}
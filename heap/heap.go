@@ -3,24 +3,56 @@ package heap
 
 import (
 	"container/heap"
+	"maps"
+	"slices"
 
 	"github.com/keilerkonzept/topk/internal/sizeof"
 )
 
 // Item is an entry in the Min-heap with a fingerprint, the item string, and its count.
 type Item struct {
-	Fingerprint uint32
-	Item        string
-	Count       uint32
+	Fingerprint uint32 `json:"fingerprint,omitempty"`
+	Item        string `json:"item"`
+	Count       uint32 `json:"count"`
+
+	// Time of the item's last update in Unix nanoseconds, if the sketch tracks it (zero otherwise).
+	LastUpdateUnixNano int64 `json:"last_update_unix_nano,omitempty"`
+	// Number of times one of the item's counters was decremented by a colliding item, if the sketch tracks it (zero otherwise).
+	DecayEvents uint64 `json:"decay_events,omitempty"`
+	// Sum of the increments of the item since it entered the heap, without decay, if the sketch tracks it (zero otherwise).
+	LifetimeCount uint64 `json:"lifetime_count,omitempty"`
 }
 
+// TiePolicy decides whether [Min.Update] admits a new item whose count equals the minimum count of a full heap.
+//
+// Of the items with the minimum count, the heap's root (the one that is replaced) is always the lexically smallest,
+// so every policy is deterministic, independent of the order of the heap's items.
+type TiePolicy int
+
+const (
+	// TieReplace admits the new item, replacing the root. This is the default: on ties, the most recently updated item wins.
+	TieReplace TiePolicy = iota
+	// TieReject rejects the new item: items must have a strictly larger count than the minimum to enter a full heap.
+	TieReject
+	// TieLexical admits the new item only if it is lexically smaller than the root it replaces.
+	TieLexical
+)
+
 // Min is a min-heap that keeps track of the top-K items.
 // It holds a slice of Items, an index map for O(1) lookup, and the total number of stored bytes for the keys.
 type Min struct {
 	K               int
+	Reserve         int       // Number of items the heap may temporarily hold beyond K, see [NewMinWithReserve].
+	TiePolicy       TiePolicy // Admission of new items tied with the minimum count of a full heap.
+	MinCount        uint32    // Items with smaller counts are never in the heap, see [Min.Update].
 	Items           []Item
 	Index           map[string]int
 	StoredKeysBytes int
+
+	// Optional callbacks called by [Min.Update] when an item enters or leaves the heap, with the item's count.
+	// They are not serialized, and not called by the other methods that remove items (e.g. [Min.Remove] and [Min.Reset]).
+	OnPromote func(item string, count uint32) `json:"-"`
+	OnEvict   func(item string, count uint32) `json:"-"`
 }
 
 // NewMin creates and returns a new Min-heap with a capacity of up to k items.
@@ -32,6 +64,52 @@ func NewMin(k int) *Min {
 	}
 }
 
+// NewMinWithReserve creates and returns a new Min-heap that keeps up to k+reserve items,
+// so that it can temporarily hold more than k candidates (e.g. while merging) before [Min.TrimToK] prunes it back to the k largest.
+func NewMinWithReserve(k, reserve int) *Min {
+	return &Min{
+		K:       k,
+		Reserve: reserve,
+		Items:   make([]Item, 0, k+reserve),
+		Index:   make(map[string]int, k+reserve),
+	}
+}
+
+// Clone returns a deep copy of the heap that shares no memory with it.
+func (me *Min) Clone() *Min {
+	out := *me
+	out.Items = slices.Clone(me.Items)
+	out.Index = maps.Clone(me.Index)
+	return &out
+}
+
+// Equal returns whether the heaps have the same K, tie policy, minimum count, and items.
+// The items are compared by fingerprint, key, and count, regardless of their order in Items, which depends on the order of the heap's updates.
+// The tracking fields (last update, decay events, lifetime count) are bookkeeping and are ignored.
+func (me *Min) Equal(other *Min) bool {
+	if me.K != other.K || me.TiePolicy != other.TiePolicy || me.MinCount != other.MinCount || len(me.Items) != len(other.Items) {
+		return false
+	}
+	for _, item := range me.Items {
+		i, ok := other.Index[item.Item]
+		if !ok {
+			return false
+		}
+		if o := other.Items[i]; o.Fingerprint != item.Fingerprint || o.Count != item.Count {
+			return false
+		}
+	}
+	return true
+}
+
+// TrimToK removes the items with the smallest counts until at most K items are left.
+func (me *Min) TrimToK() {
+	for len(me.Items) > me.K {
+		x := heap.Pop(me).(Item)
+		me.StoredKeysBytes -= len(x.Item)
+	}
+}
+
 // Ensure Min implements the heap.Interface.
 var _ heap.Interface = &Min{}
 
@@ -44,6 +122,12 @@ func (me Min) SizeBytes() int {
 	return structSize + bucketsSize + indexSize
 }
 
+// EstimateSizeBytes returns the size in bytes of an empty Min-heap with a capacity of k items,
+// as reported by [Min.SizeBytes] right after [NewMin].
+func EstimateSizeBytes(k int) int {
+	return sizeofMinStruct + k*sizeofItem + sizeof.StringIntMap
+}
+
 // Reinit reinitializes the Min heap, removing all items with a zero count.
 func (me *Min) Reinit() {
 	heap.Init(me)
@@ -54,8 +138,8 @@ func (me *Min) Reinit() {
 	}
 }
 
-// Full checks if the Min heap is full.
-func (me Min) Full() bool { return len(me.Items) == me.K }
+// Full checks if the Min heap is full, i.e. holds K items plus the reserve.
+func (me Min) Full() bool { return len(me.Items) == me.K+me.Reserve }
 
 // Len returns the number of items currently in the heap. It implements the [heap.Interface].
 func (me Min) Len() int { return len(me.Items) }
@@ -130,13 +214,48 @@ func (me Min) Get(item string) *Item {
 	return nil
 }
 
+// WouldInsert returns whether [Min.Update] with the given count would insert the item as a new entry,
+// i.e. whether it is not yet in the heap and the count is large enough to enter it.
+// Callers passing transient item strings (e.g. backed by a reused buffer) use it to decide when they must copy the string.
+func (me Min) WouldInsert(item string, count uint32) bool {
+	if me.Contains(item) || count < me.MinCount {
+		return false
+	}
+	return !me.Full() || count > me.Min() || count == me.Min() && me.admitsTie(item)
+}
+
+// admitsTie returns whether the new item may replace the root of a full heap with an equal count, see [TiePolicy].
+func (me Min) admitsTie(item string) bool {
+	switch me.TiePolicy {
+	case TieReject:
+		return false
+	case TieLexical:
+		return item < me.Items[0].Item
+	default:
+		return true
+	}
+}
+
 // Update inserts or updates an item in the heap.
 // If the count is smaller than the current minimum count and the heap is full, the update is ignored.
+// A new item whose count equals the minimum count of a full heap is admitted according to the heap's [TiePolicy].
+// If the count is smaller than MinCount, the item is not admitted, or removed if it is in the heap, even if the heap isn't full.
 // Otherwise, the item is added or updated in the heap.
+//
+// OnPromote is called when a new item is added, and OnEvict when an item is removed (the replaced minimum or an item below MinCount),
+// but neither is called when the count of an item already in the heap is updated.
 func (me *Min) Update(item string, fingerprint uint32, count uint32) bool {
 	if count < me.Min() && me.Full() { // not in top k: ignore
 		return false
 	}
+	if count < me.MinCount { // below the threshold: evict
+		if i := me.Find(item); i >= 0 {
+			evicted := me.Items[i]
+			me.Remove(item)
+			me.evicted(evicted)
+		}
+		return false
+	}
 
 	if i := me.Find(item); i >= 0 { // already in heap: update count
 		me.Items[i].Count = count
@@ -144,6 +263,10 @@ func (me *Min) Update(item string, fingerprint uint32, count uint32) bool {
 		return true
 	}
 
+	if me.Full() && count == me.Min() && !me.admitsTie(item) {
+		return false
+	}
+
 	me.StoredKeysBytes += len(item)
 
 	if !me.Full() { // heap not full: add to heap
@@ -152,13 +275,14 @@ func (me *Min) Update(item string, fingerprint uint32, count uint32) bool {
 			Fingerprint: fingerprint,
 			Item:        item,
 		})
+		me.promoted(item, count)
 		return true
 	}
 
 	// replace min on heap
-	minItem := me.Items[0].Item
-	me.StoredKeysBytes -= len(minItem)
-	delete(me.Index, minItem)
+	evicted := me.Items[0]
+	me.StoredKeysBytes -= len(evicted.Item)
+	delete(me.Index, evicted.Item)
 	me.Items[0] = Item{
 		Count:       count,
 		Fingerprint: fingerprint,
@@ -166,6 +290,45 @@ func (me *Min) Update(item string, fingerprint uint32, count uint32) bool {
 	}
 	me.Index[item] = 0
 	heap.Fix(me, 0)
+	me.evicted(evicted)
+	me.promoted(item, count)
+	return true
+}
+
+// promoted calls OnPromote, if set.
+func (me *Min) promoted(item string, count uint32) {
+	if me.OnPromote != nil {
+		me.OnPromote(item, count)
+	}
+}
+
+// evicted calls OnEvict, if set.
+func (me *Min) evicted(item Item) {
+	if me.OnEvict != nil {
+		me.OnEvict(item.Item, item.Count)
+	}
+}
+
+// Fix sets the count of an item that is already in the heap and restores the heap order,
+// returning whether the item was in the heap. Unlike [Min.Update], it also accepts counts below the heap's minimum.
+func (me *Min) Fix(item string, count uint32) bool {
+	i := me.Find(item)
+	if i < 0 {
+		return false
+	}
+	me.Items[i].Count = count
+	heap.Fix(me, i)
+	return true
+}
+
+// Remove removes the item from the heap, returning whether it was in the heap.
+func (me *Min) Remove(item string) bool {
+	i := me.Find(item)
+	if i < 0 {
+		return false
+	}
+	heap.Remove(me, i)
+	me.StoredKeysBytes -= len(item)
 	return true
 }
 
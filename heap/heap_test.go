@@ -1,6 +1,11 @@
 package heap_test
 
 import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"slices"
 	"testing"
 	"unsafe"
 
@@ -46,6 +51,28 @@ func TestMinHeap_Full(t *testing.T) {
 	}
 }
 
+// TestMinHeap_FullLenNotCap guards against comparing the capacity instead of the number of items:
+// a new heap starts empty (with length 0, not pre-filled to K), and is full once it holds K items, regardless of its capacity.
+func TestMinHeap_FullLenNotCap(t *testing.T) {
+	h := heap.NewMin(3)
+	if h.Len() != 0 || h.Full() || h.Min() != 0 {
+		t.Fatalf("Expected a new heap to be empty, got Len() = %d, Full() = %v, Min() = %d", h.Len(), h.Full(), h.Min())
+	}
+
+	h = &heap.Min{K: 2, Items: make([]heap.Item, 0, 8), Index: map[string]int{}}
+	for i, item := range []string{"a", "b"} {
+		if !h.Update(item, 0, uint32(i+2)) {
+			t.Fatalf("Expected %q to enter a heap that isn't full", item)
+		}
+	}
+	if !h.Full() {
+		t.Fatalf("Expected a heap with K items to be full despite its larger capacity")
+	}
+	if h.Update("c", 0, 1) || !h.Update("d", 0, 5) || h.Len() != 2 || h.Contains("a") {
+		t.Errorf("Expected a full heap to reject smaller items and replace its minimum, got %v", h.Items)
+	}
+}
+
 func TestMinHeap_Update(t *testing.T) {
 	h := heap.NewMin(2)
 
@@ -221,3 +248,281 @@ func TestMin_Reset(t *testing.T) {
 		t.Fatalf("expected StoredKeysBytes 0 after reset, got %d", minHeap.StoredKeysBytes)
 	}
 }
+
+func TestEstimateSizeBytes(t *testing.T) {
+	for _, k := range []int{0, 1, 3, 1000} {
+		expected := heap.NewMin(k).SizeBytes()
+		if actual := heap.EstimateSizeBytes(k); actual != expected {
+			t.Errorf("expected EstimateSizeBytes(%d) to be %d, got %d", k, expected, actual)
+		}
+	}
+}
+
+func TestMinHeap_Remove(t *testing.T) {
+	minHeap := heap.NewMin(4)
+	for i, item := range []string{"a", "bb", "ccc", "dddd"} {
+		minHeap.Update(item, uint32(i), uint32(10-i))
+	}
+
+	if !minHeap.Remove("bb") {
+		t.Fatal("expected Remove to report the item as removed")
+	}
+	if minHeap.Remove("bb") {
+		t.Fatal("expected Remove of a missing item to report false")
+	}
+	if minHeap.Contains("bb") {
+		t.Fatal("expected the removed item to be gone")
+	}
+	if minHeap.StoredKeysBytes != len("a")+len("ccc")+len("dddd") {
+		t.Fatalf("expected StoredKeysBytes %d, got %d", len("a")+len("ccc")+len("dddd"), minHeap.StoredKeysBytes)
+	}
+	for item, i := range minHeap.Index {
+		if minHeap.Items[i].Item != item {
+			t.Fatalf("index of %q points at %q", item, minHeap.Items[i].Item)
+		}
+	}
+	if minHeap.Min() != 7 {
+		t.Fatalf("expected min 7, got %d", minHeap.Min())
+	}
+}
+
+func TestMinHeap_Fix(t *testing.T) {
+	minHeap := heap.NewMin(3)
+	minHeap.Update("a", 1, 10)
+	minHeap.Update("b", 2, 20)
+	minHeap.Update("c", 3, 30)
+
+	// Fix accepts counts below the minimum of a full heap, unlike Update.
+	if !minHeap.Fix("c", 5) {
+		t.Fatal("expected Fix to find the item")
+	}
+	if minHeap.Min() != 5 || minHeap.Items[0].Item != "c" {
+		t.Fatalf("expected c with count 5 at the top of the heap, got %v", minHeap.Items[0])
+	}
+	if minHeap.Fix("d", 1) {
+		t.Fatal("expected Fix of a missing item to report false")
+	}
+}
+
+func TestMinHeap_Reserve(t *testing.T) {
+	minHeap := heap.NewMinWithReserve(3, 2)
+	for i, item := range []string{"a", "b", "c", "d", "e", "f"} {
+		minHeap.Update(item, uint32(i), uint32(10*(i+1)))
+	}
+
+	// The heap holds up to K+reserve items during the build phase.
+	if minHeap.Len() != 5 {
+		t.Fatalf("expected 5 items before trimming, got %d", minHeap.Len())
+	}
+	if minHeap.Contains("a") {
+		t.Fatal("expected the smallest item to be evicted once the reserve is full")
+	}
+
+	minHeap.TrimToK()
+
+	if minHeap.Len() != 3 {
+		t.Fatalf("expected 3 items after trimming, got %d", minHeap.Len())
+	}
+	for _, item := range []string{"d", "e", "f"} {
+		if !minHeap.Contains(item) {
+			t.Errorf("expected %q to be among the K largest", item)
+		}
+	}
+	if minHeap.StoredKeysBytes != 3 {
+		t.Fatalf("expected StoredKeysBytes 3, got %d", minHeap.StoredKeysBytes)
+	}
+	if len(minHeap.Index) != 3 {
+		t.Fatalf("expected index length 3, got %d", len(minHeap.Index))
+	}
+}
+
+func TestMinHeap_TiePolicy(t *testing.T) {
+	for _, tc := range []struct {
+		policy   heap.TiePolicy
+		expected []string
+	}{
+		{heap.TieReplace, []string{"m", "z"}},
+		{heap.TieReject, []string{"k", "m"}},
+		{heap.TieLexical, []string{"a", "m"}},
+	} {
+		// The outcome must not depend on the order in which the tied items entered the heap.
+		for _, order := range [][]string{{"k", "m"}, {"m", "k"}} {
+			h := heap.NewMin(2)
+			h.TiePolicy = tc.policy
+			for _, item := range order {
+				h.Update(item, 0, 5)
+			}
+			for _, item := range []string{"a", "z"} {
+				wouldInsert := h.WouldInsert(item, 5)
+				if inserted := h.Update(item, 0, 5); inserted != wouldInsert {
+					t.Errorf("policy %d: expected Update(%s) = WouldInsert = %v, got %v", tc.policy, item, wouldInsert, inserted)
+				}
+			}
+
+			actual := []string{h.Items[0].Item, h.Items[1].Item}
+			if actual[0] > actual[1] {
+				actual[0], actual[1] = actual[1], actual[0]
+			}
+			if actual[0] != tc.expected[0] || actual[1] != tc.expected[1] {
+				t.Errorf("policy %d, order %v: expected %v, got %v", tc.policy, order, tc.expected, actual)
+			}
+		}
+	}
+}
+
+func TestMinHeap_MinCount(t *testing.T) {
+	h := heap.NewMin(3)
+	h.MinCount = 5
+
+	if h.WouldInsert("a", 4) || h.Update("a", 0, 4) {
+		t.Error("Expected an item below the minimum count not to enter an empty heap")
+	}
+	if !h.WouldInsert("a", 5) || !h.Update("a", 0, 5) {
+		t.Error("Expected an item with the minimum count to enter the heap")
+	}
+	if h.Update("a", 0, 3) || h.Contains("a") || h.StoredKeysBytes != 0 {
+		t.Errorf("Expected an item whose count drops below the minimum count to be removed, got %v", h.Items)
+	}
+}
+
+func TestMinHeap_Callbacks(t *testing.T) {
+	var events []string
+	h := heap.NewMin(3)
+	h.MinCount = 2
+	h.OnPromote = func(item string, count uint32) { events = append(events, fmt.Sprintf("+%s:%d", item, count)) }
+	h.OnEvict = func(item string, count uint32) { events = append(events, fmt.Sprintf("-%s:%d", item, count)) }
+
+	h.Update("a", 0, 3) // push
+	h.Update("b", 0, 4) // push
+	h.Update("a", 0, 5) // count update
+	h.Update("c", 0, 1) // ignored: below MinCount
+	h.Update("b", 0, 1) // below MinCount: evicted
+	h.Update("c", 0, 6) // push
+	h.Update("d", 0, 7) // push
+	h.Update("e", 0, 8) // replaces a
+
+	expected := []string{"+a:3", "+b:4", "-b:4", "+c:6", "+d:7", "-a:5", "+e:8"}
+	if !slices.Equal(expected, events) {
+		t.Errorf("Expected callback events %v, got %v", expected, events)
+	}
+}
+
+func TestMinHeap_MarshalBinary(t *testing.T) {
+	h := heap.NewMin(4)
+	h.Update("ascii", 1, 30)
+	h.Update("größe", 2, 10)
+	h.Update("日本語", 3, 20)
+	h.Update("", 4, 5)
+
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSize := 8 + 4*12 + len("ascii") + len("größe") + len("日本語")
+	if len(data) != expectedSize || h.EncodedSizeBytes() != expectedSize {
+		t.Errorf("Expected an encoded size of %d bytes, got %d (EncodedSizeBytes = %d)", expectedSize, len(data), h.EncodedSizeBytes())
+	}
+
+	decoded := heap.NewMin(1)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(h) {
+		t.Errorf("Expected the decoded heap %v to equal %v", decoded.Items, h.Items)
+	}
+	if decoded.StoredKeysBytes != h.StoredKeysBytes || !decoded.Full() {
+		t.Errorf("Expected StoredKeysBytes = %d and a full heap, got %d and Full() = %v", h.StoredKeysBytes, decoded.StoredKeysBytes, decoded.Full())
+	}
+	for item, i := range decoded.Index {
+		if decoded.Items[i].Item != item {
+			t.Errorf("Index[%q] = %d points to %q", item, i, decoded.Items[i].Item)
+		}
+	}
+	if decoded.Min() != 5 {
+		t.Errorf("Expected the decoded heap's minimum to be 5, got %d", decoded.Min())
+	}
+	// The decoded heap keeps working as a heap.
+	decoded.Update("new", 5, 15)
+	if decoded.Contains("") || decoded.Min() != 10 {
+		t.Errorf("Expected the minimum item to be replaced, got %v", decoded.Items)
+	}
+
+	for _, n := range []int{2, 10, len(data) - 1} {
+		if err := heap.NewMin(1).UnmarshalBinary(data[:n]); !errors.Is(err, heap.ErrInvalidEncoding) {
+			t.Errorf("Expected ErrInvalidEncoding for %d bytes, got %v", n, err)
+		}
+	}
+}
+
+func TestMinHeap_UnmarshalBinary_Untrusted(t *testing.T) {
+	// A huge K must not be used to size allocations.
+	h := heap.NewMin(1)
+	if err := h.UnmarshalBinary([]byte{0xff, 0xff, 0xff, 0x7f, 0xff, 0xff, 0xff, 0x7f}); err != nil {
+		t.Fatal(err)
+	}
+	if h.K != 0x7fffffff || h.Reserve != 0x7fffffff || h.Len() != 0 || cap(h.Items) != 0 {
+		t.Errorf("Expected an empty heap with K = reserve = %d, got K = %d, reserve = %d, %d items, capacity %d", 0x7fffffff, h.K, h.Reserve, h.Len(), cap(h.Items))
+	}
+
+	// More items than K.
+	large := heap.NewMin(5)
+	for i := range 5 {
+		large.Update(fmt.Sprint(i), 0, uint32(i+1))
+	}
+	data, err := large.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[0] = 2 // K = 2
+	if err := heap.NewMin(1).UnmarshalBinary(data); !errors.Is(err, heap.ErrInvalidEncoding) {
+		t.Errorf("Expected ErrInvalidEncoding for 5 items with K = 2, got %v", err)
+	}
+}
+
+func TestMinHeap_MarshalBinary_Reserve(t *testing.T) {
+	// A heap with a reserve may hold more than K items.
+	h := heap.NewMinWithReserve(2, 3)
+	for i := range 5 {
+		h.Update(fmt.Sprint(i), 0, uint32(i+1))
+	}
+	if h.Len() <= h.K {
+		t.Fatalf("Expected more than K = %d items, got %d", h.K, h.Len())
+	}
+
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := heap.NewMin(1)
+	if err := decoded.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(h) || decoded.Reserve != h.Reserve {
+		t.Errorf("Expected the decoded heap (reserve %d) %v to equal (reserve %d) %v", decoded.Reserve, decoded.Items, h.Reserve, h.Items)
+	}
+}
+
+func TestMinHeap_Gob(t *testing.T) {
+	h := heap.NewMin(3)
+	h.TiePolicy = heap.TieReject
+	h.Update("a", 1, 3)
+	h.Update("b", 2, 5)
+	h.Get("a").LifetimeCount = 7
+	h.Get("b").DecayEvents = 2
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(h); err != nil {
+		t.Fatal(err)
+	}
+	var decoded heap.Min
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	// Unlike MarshalBinary, gob keeps the settings and the items' tracking fields.
+	if !decoded.Equal(h) || decoded.TiePolicy != heap.TieReject || decoded.Get("a").LifetimeCount != 7 || decoded.Get("b").DecayEvents != 2 {
+		t.Errorf("Expected the decoded heap to equal %+v, got %+v", h, &decoded)
+	}
+	if decoded.StoredKeysBytes != h.StoredKeysBytes {
+		t.Errorf("Expected StoredKeysBytes = %d, got %d", h.StoredKeysBytes, decoded.StoredKeysBytes)
+	}
+}
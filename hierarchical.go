@@ -0,0 +1,29 @@
+package topk
+
+import "strings"
+
+// MaxHierarchyDepth is the maximum number of levels counted by [Sketch.AddHierarchical] for a single item.
+const MaxHierarchyDepth = 16
+
+// AddHierarchical counts one instance of the item and of each of its prefixes ending before an occurrence of `sep`,
+// so that the top K is tracked at every level of a hierarchical key at once.
+// For example, with `sep = "/"`, adding "/api/v1/users" counts "/api", "/api/v1", and "/api/v1/users".
+// A separator at the start of the item does not produce an (empty) prefix.
+//
+// At most [MaxHierarchyDepth] levels are counted: the item itself, and its first MaxHierarchyDepth-1 prefixes.
+// Returns whether the item itself is in the top K.
+func (me *Sketch) AddHierarchical(item string, sep string) bool {
+	if sep != "" {
+		levels := 1
+		for i := 1; i < len(item) && levels < MaxHierarchyDepth; {
+			j := strings.Index(item[i:], sep)
+			if j < 0 {
+				break
+			}
+			me.Incr(item[:i+j])
+			levels++
+			i += j + len(sep)
+		}
+	}
+	return me.Incr(item)
+}
@@ -0,0 +1,45 @@
+package topk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_AddHierarchical(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(1024))
+	sketch.AddHierarchical("/a/b/c", "/")
+	sketch.AddHierarchical("/a/b/d", "/")
+	sketch.AddHierarchical("/a/e", "/")
+	sketch.AddHierarchical("x::y", "::")
+
+	for item, expected := range map[string]uint32{
+		"/a":     3,
+		"/a/b":   2,
+		"/a/b/c": 1,
+		"/a/b/d": 1,
+		"/a/e":   1,
+		"x":      1,
+		"x::y":   1,
+		"":       0,
+		"/":      0,
+	} {
+		if actual := sketch.Count(item); actual != expected {
+			t.Errorf("Expected Count(%q) = %d, got %d", item, expected, actual)
+		}
+	}
+}
+
+func TestSketch_AddHierarchical_MaxDepth(t *testing.T) {
+	sketch := topk.New(100, topk.WithWidth(1024))
+	item := strings.Repeat("/x", 2*topk.MaxHierarchyDepth)
+	sketch.AddHierarchical(item, "/")
+
+	if actual := len(sketch.SortedSlice()); actual != topk.MaxHierarchyDepth {
+		t.Errorf("Expected %d levels to be counted, got %d", topk.MaxHierarchyDepth, actual)
+	}
+	if !sketch.Query(item) {
+		t.Error("Expected the full item to be counted")
+	}
+}
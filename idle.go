@@ -0,0 +1,27 @@
+package topk
+
+import "time"
+
+// EvictIdle removes the items from the top-K heap that have not been updated within the given duration,
+// and returns the number of removed items. The buckets are left untouched,
+// so an evicted item re-enters the top K with its estimated count once it is counted again.
+//
+// EvictIdle requires [WithLastUpdateTracking]; without it, no items are removed.
+// Items without an update time (e.g. placed in the heap by [Sketch.RebuildHeap] or decoding) are kept,
+// until they are counted again and get one.
+func (me *Sketch) EvictIdle(olderThan time.Duration) int {
+	if !me.TrackLastUpdate {
+		return 0
+	}
+	cutoff := time.Now().Add(-olderThan).UnixNano()
+	var idle []string
+	for _, item := range me.Heap.Items {
+		if item.LastUpdateUnixNano != 0 && item.LastUpdateUnixNano < cutoff {
+			idle = append(idle, item.Item)
+		}
+	}
+	for _, item := range idle {
+		me.Heap.Remove(item)
+	}
+	return len(idle)
+}
@@ -0,0 +1,65 @@
+package topk_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_EvictIdle(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithLastUpdateTracking())
+	sketch.Add("idle", 10)
+	sketch.Add("active", 5)
+	time.Sleep(50 * time.Millisecond)
+	sketch.Add("active", 5)
+	sketch.Add("new", 1)
+
+	if n := sketch.EvictIdle(25 * time.Millisecond); n != 1 {
+		t.Errorf("Expected 1 evicted item, got %d", n)
+	}
+	if sketch.Query("idle") {
+		t.Error("Expected the idle item to be evicted")
+	}
+	for _, item := range []string{"active", "new"} {
+		if !sketch.Query(item) {
+			t.Errorf("Expected %s to survive", item)
+		}
+	}
+	if c := sketch.Count("idle"); c != 10 {
+		t.Errorf("Expected the evicted item's buckets to be untouched, got count %d", c)
+	}
+	if expected, actual := len("active")+len("new"), sketch.Heap.StoredKeysBytes; actual != expected {
+		t.Errorf("Expected %d stored key bytes, got %d", expected, actual)
+	}
+}
+
+func TestSketch_EvictIdle_AfterRebuildHeap(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithLastUpdateTracking())
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	sketch.RebuildHeap()
+	sketch.Add("c", 1)
+	time.Sleep(10 * time.Millisecond)
+
+	// The rebuilt items have no update time, which must not count as idle; c was counted before the cutoff.
+	if n := sketch.EvictIdle(5 * time.Millisecond); n != 1 {
+		t.Errorf("Expected 1 evicted item, got %d", n)
+	}
+	for _, item := range []string{"a", "b"} {
+		if !sketch.Query(item) {
+			t.Errorf("Expected the rebuilt item %s to survive", item)
+		}
+	}
+	if sketch.Query("c") {
+		t.Error("Expected c to be evicted")
+	}
+}
+
+func TestSketch_EvictIdle_NoTracking(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024))
+	sketch.Add("a", 1)
+	if n := sketch.EvictIdle(0); n != 0 {
+		t.Errorf("Expected no evictions without last-update tracking, got %d", n)
+	}
+}
@@ -0,0 +1,61 @@
+// Package bloom implements a small blocked Bloom filter over string keys.
+package bloom
+
+import (
+	"math/bits"
+
+	"github.com/OneOfOne/xxhash"
+)
+
+const (
+	bitsPerKey = 16
+	hashSeed   = 0x5bd1e995
+)
+
+// Filter is a blocked Bloom filter: each key sets four bits within a single 64-bit word,
+// so that a lookup costs one hash computation and one memory access.
+// It never reports a false negative for a key that has been added since the last [Filter.Reset].
+type Filter struct {
+	Words []uint64
+	Mask  uint64 // len(Words)-1
+}
+
+// New returns a filter sized for up to n keys.
+func New(n int) *Filter {
+	words := max(1, uint64(n)*bitsPerKey/64)
+	words = 1 << bits.Len64(words-1) // round up to a power of two
+	return &Filter{
+		Words: make([]uint64, words),
+		Mask:  words - 1,
+	}
+}
+
+func (me *Filter) locate(key string) (word *uint64, mask uint64) {
+	h := xxhash.ChecksumString64S(key, hashSeed)
+	word = &me.Words[h&me.Mask]
+	h >>= 40
+	mask = 1<<(h&63) | 1<<(h>>6&63) | 1<<(h>>12&63) | 1<<(h>>18&63)
+	return word, mask
+}
+
+// Add adds the key to the filter.
+func (me *Filter) Add(key string) {
+	word, mask := me.locate(key)
+	*word |= mask
+}
+
+// MayContain returns false if the key has definitely not been added to the filter, and true if it may have been.
+func (me *Filter) MayContain(key string) bool {
+	word, mask := me.locate(key)
+	return *word&mask == mask
+}
+
+// Reset removes all keys from the filter.
+func (me *Filter) Reset() {
+	clear(me.Words)
+}
+
+// SizeBytes returns the size of the filter's bit set in bytes.
+func (me *Filter) SizeBytes() int {
+	return len(me.Words) * 8
+}
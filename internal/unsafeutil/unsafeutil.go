@@ -0,0 +1,10 @@
+// Package unsafeutil implements allocation-free conversions between byte slices and strings.
+package unsafeutil
+
+import "unsafe"
+
+// String returns a string that shares the memory of b.
+// The string must not be used after b is modified, and must not be retained beyond b's lifetime.
+func String(b []byte) string {
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
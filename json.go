@@ -0,0 +1,52 @@
+package topk
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonItem is the JSON representation of a top-K item in [Sketch.TopKJSON].
+type jsonItem struct {
+	Item  string `json:"item"`
+	Count uint32 `json:"count"`
+}
+
+// TopKJSON returns the top K items in descending count order (see [Sketch.SortedSlice]) as a JSON array
+// of `{"item": ..., "count": ...}` objects. An empty sketch gives `[]`.
+//
+// This is meant for reporting, e.g. serving the current top K over HTTP; the buckets are not included,
+// so the sketch can't be restored from it. Encoding the sketch itself with [json.Marshal] keeps all of its exported fields.
+func (me *Sketch) TopKJSON() ([]byte, error) {
+	items := me.SortedSlice()
+	out := make([]jsonItem, len(items))
+	for i, item := range items {
+		out[i] = jsonItem{Item: item.Item, Count: item.Count}
+	}
+	return json.Marshal(out)
+}
+
+// WriteTopK writes the top K items in descending count order (see [Sketch.SortedSlice]) to w as newline-delimited JSON,
+// one `{"item": ..., "count": ...}` object per line, e.g. for periodically dumping the top K to a log.
+// Each line is written to w as soon as it is encoded. It returns the number of bytes written.
+func (me *Sketch) WriteTopK(w io.Writer) (int, error) {
+	cw := countingWriter{w: w}
+	enc := json.NewEncoder(&cw)
+	for _, item := range me.SortedSlice() {
+		if err := enc.Encode(jsonItem{Item: item.Item, Count: item.Count}); err != nil {
+			return cw.n, err
+		}
+	}
+	return cw.n, nil
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (me *countingWriter) Write(p []byte) (int, error) {
+	n, err := me.w.Write(p)
+	me.n += n
+	return n, err
+}
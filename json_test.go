@@ -0,0 +1,104 @@
+package topk_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func TestSketch_TopKJSON(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3), topk.WithDecay(0))
+	data, err := sketch.TopKJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != `[]` {
+		t.Errorf("Expected an empty sketch to encode as [], got %s", s)
+	}
+
+	sketch.Add("a", 3)
+	sketch.Add("b", 5)
+	sketch.Add("c", 3)
+	data, err = sketch.TopKJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"item":"b","count":5},{"item":"a","count":3},{"item":"c","count":3}]`
+	if s := string(data); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+}
+
+func TestSketch_JSON(t *testing.T) {
+	// The sketch itself encodes all of its exported fields, so it can be restored.
+	sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 3)
+	sketch.Add("b", 5)
+	data, err := json.Marshal(sketch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded topk.Sketch
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(sketch) {
+		t.Errorf("Expected the decoded sketch to equal the original, got %s", data)
+	}
+}
+
+func TestItem_JSON(t *testing.T) {
+	data, err := json.Marshal([]heap.Item{{Item: "a", Count: 3}, {Fingerprint: 7, Item: "b", Count: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `[{"item":"a","count":3},{"fingerprint":7,"item":"b","count":1}]`
+	if s := string(data); s != expected {
+		t.Errorf("Expected %s, got %s", expected, s)
+	}
+}
+
+func TestSketch_WriteTopK(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 3)
+	sketch.Add("b", 5)
+	sketch.Add("c\n\"", 3)
+
+	var buf bytes.Buffer
+	n, err := sketch.WriteTopK(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != buf.Len() {
+		t.Errorf("Expected %d bytes written, got %d", buf.Len(), n)
+	}
+	if lines := bytes.Count(buf.Bytes(), []byte("\n")); lines != 3 {
+		t.Errorf("Expected 3 lines, got %d", lines)
+	}
+
+	type line struct {
+		Item  string `json:"item"`
+		Count uint32 `json:"count"`
+	}
+	var actual []line
+	dec := json.NewDecoder(&buf)
+	for {
+		var l line
+		if err := dec.Decode(&l); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		actual = append(actual, l)
+	}
+	expected := []line{{"b", 5}, {"a", 3}, {"c\n\"", 3}}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("WriteTopK mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,120 @@
+package topk
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// ErrIncompatibleSketches is returned when merging sketches whose parameters don't match.
+var ErrIncompatibleSketches = errors.New("topk: incompatible sketches")
+
+// Merge combines the counts of `other` into the sketch.
+// Both sketches must have the same width, depth, decay, and hash seed.
+//
+// Of each pair of corresponding buckets, the one with the larger count is kept, together with its fingerprint;
+// on equal counts, the sketch's own bucket is kept. This applies to buckets holding the same fingerprint as well,
+// so an item counted by both sketches keeps the larger of its two counts, rather than their sum.
+// The total counts (see [Sketch.TotalCount]) are summed.
+// Afterwards, the heap items of both sketches are re-counted from the merged buckets and offered to the top-K heap.
+// The result is approximate (just like the sketches themselves) and deterministic, and never exceeds the sum of the two sketches' counts.
+func (me *Sketch) Merge(other *Sketch) error {
+	return me.MergeScaled(other, 1)
+}
+
+// MergeScaled is like [Sketch.Merge], but multiplies `other`'s counts by `factor` (rounding according to the sketch's rounding mode, see [WithRoundingMode]) before merging them.
+// This is useful for aggregating partial sketches with exponentially decaying weights, e.g. a factor of 0.5 for an older partial.
+//
+// A factor of 0 leaves the sketch unchanged, a factor of 1 is equivalent to [Sketch.Merge].
+func (me *Sketch) MergeScaled(other *Sketch, factor float32) error {
+	if err := me.checkCompatible(other); err != nil {
+		return err
+	}
+	if factor < 0 || math.IsNaN(float64(factor)) {
+		return fmt.Errorf("topk: invalid merge scaling factor %v", factor)
+	}
+	if factor == 0 {
+		return nil
+	}
+
+	for i := range me.Buckets {
+		b := &me.Buckets[i]
+		ob := &other.Buckets[i]
+		count := me.scaleCount(ob.Count, factor)
+		if count > b.Count {
+			b.Fingerprint = ob.Fingerprint
+			b.Count = count
+		}
+	}
+
+	me.Total += me.scaleTotal(other.Total, factor)
+
+	candidates := make([]heap.Item, 0, len(me.Heap.Items)+len(other.Heap.Items))
+	candidates = append(candidates, me.Heap.Items...)
+	candidates = append(candidates, other.Heap.Items...)
+	for _, c := range candidates {
+		me.updateHeap(c.Item, c.Fingerprint, me.bucketCount(c.Item, c.Fingerprint))
+	}
+	return nil
+}
+
+func (me *Sketch) checkCompatible(other *Sketch) error {
+	if me.Width != other.Width {
+		return fmt.Errorf("%w: width %d != %d", ErrIncompatibleSketches, me.Width, other.Width)
+	}
+	if me.Depth != other.Depth {
+		return fmt.Errorf("%w: depth %d != %d", ErrIncompatibleSketches, me.Depth, other.Depth)
+	}
+	if me.Decay != other.Decay {
+		return fmt.Errorf("%w: decay %v != %v", ErrIncompatibleSketches, me.Decay, other.Decay)
+	}
+	if me.Seed != other.Seed {
+		return fmt.Errorf("%w: seed %d != %d", ErrIncompatibleSketches, me.Seed, other.Seed)
+	}
+	if !slices.Equal(me.RowWidths, other.RowWidths) {
+		return fmt.Errorf("%w: row widths %v != %v", ErrIncompatibleSketches, me.RowWidths, other.RowWidths)
+	}
+	// The other sketch may have been decoded from untrusted input, so its fields need not be consistent with its parameters.
+	if len(me.Buckets) != len(other.Buckets) {
+		return fmt.Errorf("%w: %d buckets != %d", ErrIncompatibleSketches, len(me.Buckets), len(other.Buckets))
+	}
+	if other.Heap == nil {
+		return fmt.Errorf("%w: missing heap", ErrIncompatibleSketches)
+	}
+	if other.Heap.K < 0 || other.Heap.Reserve < 0 || len(other.Heap.Items) > other.Heap.K+other.Heap.Reserve {
+		return fmt.Errorf("%w: heap with %d items for K = %d (reserve %d)", ErrIncompatibleSketches, len(other.Heap.Items), other.Heap.K, other.Heap.Reserve)
+	}
+	return nil
+}
+
+func addSaturating(a, b uint32) uint32 {
+	if c := a + b; c >= a {
+		return c
+	}
+	return math.MaxUint32
+}
+
+// MergeResults combines top-k results (e.g. from [Sketch.SortedSlice]) from several sources into a global top `k`,
+// summing the counts (saturating at [math.MaxUint32]) of equal item strings across the result lists.
+// Unlike [Sketch.Merge], it works for results of sketches with different parameters, or of other top-k implementations.
+//
+// Items that didn't make it into a source's top k are missing from its result, so the summed counts are lower bounds.
+func MergeResults(k int, results ...[]heap.Item) []heap.Item {
+	index := make(map[string]int)
+	var merged []heap.Item
+	for _, result := range results {
+		for _, item := range result {
+			if i, ok := index[item.Item]; ok {
+				merged[i].Count = addSaturating(merged[i].Count, item.Count)
+				continue
+			}
+			index[item.Item] = len(merged)
+			merged = append(merged, heap.Item{Fingerprint: item.Fingerprint, Item: item.Item, Count: item.Count})
+		}
+	}
+	merged = sortedItems(merged)
+	return merged[:min(k, len(merged))]
+}
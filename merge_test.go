@@ -0,0 +1,179 @@
+package topk_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func newMergeTestSketch(offset int) *topk.Sketch {
+	sketch := topk.New(5, topk.WithWidth(4096), topk.WithDepth(3))
+	for i := range 10 {
+		sketch.Add(fmt.Sprintf("item%d", i+offset), uint32(10*(i+1)))
+	}
+	return sketch
+}
+
+func TestSketch_MergeScaled_Zero(t *testing.T) {
+	sketch := newMergeTestSketch(0)
+	expectedBuckets := slices.Clone(sketch.Buckets)
+	expectedTopK := sketch.SortedSlice()
+
+	if err := sketch.MergeScaled(newMergeTestSketch(5), 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(expectedBuckets, sketch.Buckets); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(expectedTopK, sketch.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSketch_MergeScaled_One(t *testing.T) {
+	merged := newMergeTestSketch(0)
+	if err := merged.Merge(newMergeTestSketch(5)); err != nil {
+		t.Fatal(err)
+	}
+	scaled := newMergeTestSketch(0)
+	if err := scaled.MergeScaled(newMergeTestSketch(5), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(merged.Buckets, scaled.Buckets); diff != "" {
+		t.Error(diff)
+	}
+	if diff := cmp.Diff(merged.SortedSlice(), scaled.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSketch_MergeScaled_Half(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(4096), topk.WithDepth(3))
+	sketch.Add("a", 10)
+	other := topk.New(3, topk.WithWidth(4096), topk.WithDepth(3))
+	other.Add("a", 10)
+	other.Add("b", 30)
+
+	if err := sketch.MergeScaled(other, 0.5); err != nil {
+		t.Fatal(err)
+	}
+
+	// The scaled count of a (5) is smaller than the sketch's own (10), which is kept.
+	if actual := sketch.Count("a"); actual != 10 {
+		t.Errorf("Expected Count(a) = 10, got %d", actual)
+	}
+	if actual := sketch.Count("b"); actual != 15 {
+		t.Errorf("Expected Count(b) = 15, got %d", actual)
+	}
+}
+
+func TestSketch_MergeScaled_Incompatible(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3))
+
+	for _, other := range []*topk.Sketch{
+		topk.New(3, topk.WithWidth(512), topk.WithDepth(3)),
+		topk.New(3, topk.WithWidth(256), topk.WithDepth(4)),
+		topk.New(3, topk.WithWidth(256), topk.WithDepth(3), topk.WithDecay(0.5)),
+	} {
+		if err := sketch.MergeScaled(other, 0.5); !errors.Is(err, topk.ErrIncompatibleSketches) {
+			t.Errorf("Expected ErrIncompatibleSketches, got %v", err)
+		}
+	}
+	if err := sketch.MergeScaled(topk.New(3, topk.WithWidth(256), topk.WithDepth(3)), -1); err == nil {
+		t.Error("Expected an error for a negative scaling factor")
+	}
+}
+
+func TestSketch_Merge_Disjoint(t *testing.T) {
+	a := topk.New(4, topk.WithWidth(4096), topk.WithDepth(3))
+	b := topk.New(4, topk.WithWidth(4096), topk.WithDepth(3))
+	for i := range 4 {
+		a.Add(fmt.Sprintf("a%d", i), uint32(10*(i+1)))
+		b.Add(fmt.Sprintf("b%d", i), uint32(10*(i+1)+5))
+	}
+
+	if err := a.Merge(b); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []heap.Item{
+		{Fingerprint: topk.Fingerprint("b3"), Item: "b3", Count: 45},
+		{Fingerprint: topk.Fingerprint("a3"), Item: "a3", Count: 40},
+		{Fingerprint: topk.Fingerprint("b2"), Item: "b2", Count: 35},
+		{Fingerprint: topk.Fingerprint("a2"), Item: "a2", Count: 30},
+	}
+	if diff := cmp.Diff(expected, a.SortedSlice()); diff != "" {
+		t.Errorf("Merged top K mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_Merge_Overlapping(t *testing.T) {
+	build := func(counts map[string]uint32) *topk.Sketch {
+		s := topk.New(3, topk.WithWidth(4096), topk.WithDepth(3))
+		for item, count := range counts {
+			s.Add(item, count)
+		}
+		return s
+	}
+	countsA := map[string]uint32{"item0": 10, "item1": 11, "item2": 12, "item3": 13, "item4": 14, "item5": 15}
+	countsB := map[string]uint32{"item3": 30, "item4": 5, "item5": 15, "item6": 16, "item7": 17, "item8": 18}
+
+	// Both sketches count item3..item5; each of them keeps the larger of its two counts, not their sum.
+	merged := build(countsA)
+	if err := merged.Merge(build(countsB)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range 9 {
+		item := fmt.Sprintf("item%d", i)
+		if expected, actual := max(countsA[item], countsB[item]), merged.Count(item); actual != expected {
+			t.Errorf("Expected merged Count(%s) = %d, got %d", item, expected, actual)
+		}
+	}
+	expected := []heap.Item{
+		{Fingerprint: topk.Fingerprint("item3"), Item: "item3", Count: 30},
+		{Fingerprint: topk.Fingerprint("item8"), Item: "item8", Count: 18},
+		{Fingerprint: topk.Fingerprint("item7"), Item: "item7", Count: 17},
+	}
+	if diff := cmp.Diff(expected, merged.SortedSlice()); diff != "" {
+		t.Errorf("Merged top K mismatch (-want +got):\n%s", diff)
+	}
+
+	// Merging is deterministic.
+	again := build(countsA)
+	if err := again.Merge(build(countsB)); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(merged.Buckets, again.Buckets); diff != "" {
+		t.Errorf("Expected identical merge results (-first +second):\n%s", diff)
+	}
+}
+
+func TestMergeResults(t *testing.T) {
+	item := func(name string, count uint32) heap.Item {
+		return heap.Item{Fingerprint: topk.Fingerprint(name), Item: name, Count: count}
+	}
+	results := [][]heap.Item{
+		{item("a", 10), item("b", 8), item("c", 1)},
+		{item("b", 5), item("d", 12)},
+		{item("a", 4), item("c", 2), item("e", 3)},
+	}
+
+	expected := []heap.Item{item("a", 14), item("b", 13), item("d", 12)}
+	if diff := cmp.Diff(expected, topk.MergeResults(3, results...)); diff != "" {
+		t.Errorf("Merged results mismatch (-want +got):\n%s", diff)
+	}
+	if n := len(topk.MergeResults(10, results...)); n != 5 {
+		t.Errorf("Expected all 5 distinct items for k = 10, got %d", n)
+	}
+	if diff := cmp.Diff(results[0][0], item("a", 10)); diff != "" {
+		t.Errorf("Expected the inputs to be left unchanged:\n%s", diff)
+	}
+}
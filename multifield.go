@@ -0,0 +1,58 @@
+package topk
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// MultiField tracks the top K values of each field of structured records (e.g. the paths, users, and status codes of log lines)
+// in one pass, with a separate [Sketch] per field.
+// The sketch of a field is created with the same options when the field is first added.
+type MultiField struct {
+	K        int                // Number of top values tracked per field.
+	Sketches map[string]*Sketch // Sketches by field name.
+
+	sketchOptions []Option
+}
+
+// NewMultiField returns an empty [MultiField] whose per-field sketches are created as [New](k, opts...).
+func NewMultiField(k int, opts ...Option) *MultiField {
+	return &MultiField{
+		K:             k,
+		Sketches:      make(map[string]*Sketch),
+		sketchOptions: opts,
+	}
+}
+
+// Add counts one instance of each field's value in the field's sketch.
+func (me *MultiField) Add(fields map[string]string) {
+	for field, value := range fields {
+		me.sketch(field).Incr(value)
+	}
+}
+
+// sketch returns the sketch of the field, creating it if the field hasn't been added before.
+func (me *MultiField) sketch(field string) *Sketch {
+	s, ok := me.Sketches[field]
+	if !ok {
+		s = New(me.K, me.sketchOptions...)
+		me.Sketches[field] = s
+	}
+	return s
+}
+
+// Fields returns the names of the fields that have been added, in lexicographic order.
+func (me *MultiField) Fields() []string {
+	return slices.Sorted(maps.Keys(me.Sketches))
+}
+
+// SortedSlice returns the field's top K values as a sorted slice (see [Sketch.SortedSlice]), or nil if the field hasn't been added.
+func (me *MultiField) SortedSlice(field string) []heap.Item {
+	s, ok := me.Sketches[field]
+	if !ok {
+		return nil
+	}
+	return s.SortedSlice()
+}
@@ -0,0 +1,49 @@
+package topk_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func TestMultiField(t *testing.T) {
+	m := topk.NewMultiField(2, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	for _, record := range []map[string]string{
+		{"path": "/a", "status": "200"},
+		{"path": "/a", "status": "500"},
+		{"path": "/b", "status": "200"},
+		{"path": "/a", "status": "200", "user": "x"},
+		{"path": "/c", "status": "404"},
+		{"path": "/b"},
+	} {
+		m.Add(record)
+	}
+
+	if diff := cmp.Diff([]string{"path", "status", "user"}, m.Fields()); diff != "" {
+		t.Errorf("Fields mismatch (-want +got):\n%s", diff)
+	}
+	for field, expected := range map[string][]heap.Item{
+		"path": {
+			{Fingerprint: topk.Fingerprint("/a"), Item: "/a", Count: 3},
+			{Fingerprint: topk.Fingerprint("/b"), Item: "/b", Count: 2},
+		},
+		"status": {
+			{Fingerprint: topk.Fingerprint("200"), Item: "200", Count: 3},
+			{Fingerprint: topk.Fingerprint("404"), Item: "404", Count: 1},
+		},
+		"user": {
+			{Fingerprint: topk.Fingerprint("x"), Item: "x", Count: 1},
+		},
+		"missing": nil,
+	} {
+		if diff := cmp.Diff(expected, m.SortedSlice(field)); diff != "" {
+			t.Errorf("SortedSlice(%s) mismatch (-want +got):\n%s", field, diff)
+		}
+	}
+	// The fields' sketches are independent: "/a" isn't counted as a status.
+	if c := m.Sketches["status"].Count("/a"); c != 0 {
+		t.Errorf("Expected Count(/a) = 0 in the status sketch, got %d", c)
+	}
+}
@@ -0,0 +1,67 @@
+package topk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// AddNDJSON counts one instance of the value at `fieldPath` in each line of the newline-delimited JSON stream `r`.
+// The path is a dot-separated sequence of object keys, e.g. `user.id` selects the `id` field of the top-level `user` object.
+// String values are counted as they are, numbers and booleans by their JSON text.
+//
+// Lines that aren't valid JSON, or that have no string, number, or boolean at the path, are skipped and returned as the skipped count.
+// Blank lines are ignored.
+// The returned error is non-nil only if reading from `r` fails.
+func (me *Sketch) AddNDJSON(r io.Reader, fieldPath string) (skipped int, err error) {
+	path := strings.Split(fieldPath, ".")
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if item, ok := ndjsonField(line, path); ok {
+				me.Incr(item)
+			} else {
+				skipped++
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return skipped, nil
+		}
+		if err != nil {
+			return skipped, err
+		}
+	}
+}
+
+func ndjsonField(line []byte, path []string) (string, bool) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return "", false
+	}
+	for _, key := range path {
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if v, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,48 @@
+package topk_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_AddNDJSON(t *testing.T) {
+	input := `{"user": {"id": "alice"}, "path": "/a"}
+{"user": {"id": "bob"}}
+
+{"user": {"id": "alice", "name": "Alice"}}
+not json
+{"user": {"name": "no id"}}
+{"user": "not an object"}
+{"user": {"id": {"nested": true}}}
+{"user": {"id": 42}}
+{"user": {"id": "alice"}}`
+
+	sketch := topk.New(3, topk.WithWidth(1024))
+	skipped, err := sketch.AddNDJSON(strings.NewReader(input), "user.id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 4 {
+		t.Errorf("Expected 4 skipped lines, got %d", skipped)
+	}
+	for item, expected := range map[string]uint32{"alice": 3, "bob": 1, "42": 1} {
+		if actual := sketch.Count(item); actual != expected {
+			t.Errorf("Expected Count(%s) = %d, got %d", item, expected, actual)
+		}
+	}
+}
+
+func TestSketch_AddNDJSON_ReadError(t *testing.T) {
+	sketch := topk.New(3)
+	r := iotest.TimeoutReader(strings.NewReader(`{"id": "a"}` + "\n"))
+	if _, err := sketch.AddNDJSON(r, "id"); !errors.Is(err, iotest.ErrTimeout) {
+		t.Errorf("Expected the read error to be returned, got %v", err)
+	}
+	if !sketch.Query("a") {
+		t.Error("Expected the lines read before the error to be counted")
+	}
+}
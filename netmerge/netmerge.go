@@ -0,0 +1,188 @@
+// Package netmerge implements a minimal protocol for aggregating [topk.Sketch] instances over a network connection.
+//
+// A [Client] counts items into a local sketch and periodically ships all of it to a [Server].
+// The server merges every sketch it receives into a central sketch using [topk.Sketch.Merge].
+// Since Merge keeps the larger of two counts, shipping the same counts again doesn't inflate them,
+// and each client's latest sketch is reflected in the central sketch whether or not earlier ones were received.
+// Clients should count disjoint parts of the stream (e.g. shards): an item counted by several clients gets the largest of their counts.
+//
+// On the wire, each sketch is a single frame: a 4-byte big-endian length followed by the gob-encoded sketch.
+package netmerge
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/keilerkonzept/topk"
+)
+
+// MaxFrameSize is the largest frame (encoded sketch) that a [Server] accepts.
+const MaxFrameSize = 1 << 26
+
+// ErrFrameTooLarge is returned when a frame exceeds [MaxFrameSize].
+var ErrFrameTooLarge = errors.New("netmerge: frame too large")
+
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > MaxFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, len(payload))
+	}
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(payload)))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > MaxFrameSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, n)
+	}
+	// The length header is untrusted, so the payload buffer grows with the data actually received instead of being allocated up front.
+	var payload bytes.Buffer
+	if _, err := io.CopyN(&payload, r, int64(n)); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload.Bytes(), nil
+}
+
+// Server merges the sketches it receives into a central sketch.
+// It is safe for concurrent use.
+type Server struct {
+	mu     sync.Mutex
+	sketch *topk.Sketch
+}
+
+// NewServer returns a server that merges received sketches into the given sketch.
+// The sketch must not be accessed directly while the server is running; use [Server.View] instead.
+func NewServer(sketch *topk.Sketch) *Server {
+	return &Server{sketch: sketch}
+}
+
+// Serve accepts connections on the listener and serves each of them in a new goroutine (see [Server.ServeConn]).
+// It returns when the listener fails, e.g. because it has been closed.
+func (me *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			_ = me.ServeConn(conn)
+		}()
+	}
+}
+
+// ServeConn reads frames from the connection and merges each sketch into the central sketch.
+// The central sketch's total count (see [topk.Sketch.TotalCount]) includes only the latest sketch's total of each connection,
+// since each sketch received on a connection also counts everything in the earlier ones.
+// It returns nil when the connection is closed cleanly between frames,
+// and an error for malformed frames or sketches that can't be merged (e.g. with the wrong number of buckets or without a heap).
+func (me *Server) ServeConn(conn io.Reader) error {
+	var shipped uint64 // Total of the previous sketch received on the connection.
+	for {
+		payload, err := readFrame(conn)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var sketch topk.Sketch
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&sketch); err != nil {
+			return fmt.Errorf("netmerge: decode sketch: %w", err)
+		}
+		me.mu.Lock()
+		err = me.sketch.Merge(&sketch)
+		if err == nil {
+			me.sketch.Total -= min(me.sketch.Total, shipped)
+			shipped = sketch.Total
+		}
+		me.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// View calls f with the central sketch while holding the server's lock.
+// The sketch must not be retained after f returns.
+func (me *Server) View(f func(*topk.Sketch)) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	f(me.sketch)
+}
+
+// Client counts items into a local sketch and ships it to a [Server].
+// It is safe for concurrent use.
+type Client struct {
+	mu     sync.Mutex
+	conn   io.Writer
+	sketch *topk.Sketch
+}
+
+// NewClient returns a client that counts into the given sketch and ships it over the connection.
+// The sketch must have the same width and depth as the server's sketch, and must not be accessed directly while the client is in use.
+func NewClient(conn io.Writer, sketch *topk.Sketch) *Client {
+	return &Client{conn: conn, sketch: sketch}
+}
+
+// Add increments the given item's count in the local sketch.
+func (me *Client) Add(item string, increment uint32) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.sketch.Add(item, increment)
+}
+
+// Flush ships the local sketch to the server. The local sketch keeps counting, so the next flush ships all counts again,
+// including those added since this flush; the server's merge keeps the larger counts, so they aren't counted twice.
+func (me *Client) Flush() error {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(me.sketch); err != nil {
+		return fmt.Errorf("netmerge: encode sketch: %w", err)
+	}
+	if err := writeFrame(me.conn, buf.Bytes()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Run calls [Client.Flush] every interval until the context is done or a flush fails.
+// It flushes once more before returning due to the context.
+func (me *Client) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			if err := me.Flush(); err != nil {
+				return err
+			}
+			return ctx.Err()
+		case <-ticker.C:
+			if err := me.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package netmerge_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+	"github.com/keilerkonzept/topk/netmerge"
+)
+
+func TestClientServer(t *testing.T) {
+	newSketch := func() *topk.Sketch {
+		return topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(1))
+	}
+	server := netmerge.NewServer(newSketch())
+	clientConn, serverConn := net.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- server.ServeConn(serverConn) }()
+
+	client := netmerge.NewClient(clientConn, newSketch())
+	for i := range 10 {
+		client.Add(fmt.Sprintf("item%d", i), uint32(i+1))
+	}
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	client.Add("item0", 100)
+	if err := client.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	clientConn.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	server.View(func(s *topk.Sketch) {
+		// Both flushes ship all counts; the second one's total replaces the first one's.
+		if total := s.TotalCount(); total != 155 {
+			t.Errorf("Expected TotalCount() = 155, got %d", total)
+		}
+		for item, want := range map[string]uint32{"item0": 101, "item9": 10, "item8": 9} {
+			if !s.Query(item) {
+				t.Errorf("Expected %s in the top K", item)
+			}
+			if got := s.Count(item); got != want {
+				t.Errorf("Expected Count(%s) = %d, got %d", item, want, got)
+			}
+		}
+	})
+}
+
+func TestServer_MalformedSketches(t *testing.T) {
+	newSketch := func() *topk.Sketch {
+		return topk.New(3, topk.WithWidth(8), topk.WithDepth(2), topk.WithDecay(1))
+	}
+	tooFewBuckets := newSketch()
+	tooFewBuckets.Buckets = tooFewBuckets.Buckets[:1]
+	tooFewBuckets.Buckets[0] = topk.Bucket{Fingerprint: 1, Count: 1}
+	nilHeap := newSketch()
+	nilHeap.Heap = nil
+	tooManyItems := newSketch()
+	for i := range 10 {
+		tooManyItems.Heap.Items = append(tooManyItems.Heap.Items, heap.Item{Item: fmt.Sprint(i), Count: 1})
+	}
+
+	for _, tc := range []struct {
+		name     string
+		sketch   *topk.Sketch
+		expected error
+	}{
+		{name: "TooFewBuckets", sketch: tooFewBuckets, expected: topk.ErrIncompatibleSketches},
+		{name: "NilHeap", sketch: nilHeap, expected: topk.ErrIncompatibleSketches},
+		{name: "TooManyItems", sketch: tooManyItems, expected: heap.ErrInvalidEncoding},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := gob.NewEncoder(&buf).Encode(tc.sketch); err != nil {
+				t.Fatal(err)
+			}
+			data := append(binary.BigEndian.AppendUint32(nil, uint32(buf.Len())), buf.Bytes()...)
+			server := netmerge.NewServer(newSketch())
+			if err := server.ServeConn(bytes.NewReader(data)); !errors.Is(err, tc.expected) {
+				t.Errorf("Expected %v, got %v", tc.expected, err)
+			}
+		})
+	}
+
+	t.Run("TruncatedFrame", func(t *testing.T) {
+		// A header announcing the largest frame, followed by only a few bytes.
+		data := append(binary.BigEndian.AppendUint32(nil, netmerge.MaxFrameSize), 1, 2, 3)
+		server := netmerge.NewServer(newSketch())
+		if err := server.ServeConn(bytes.NewReader(data)); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+		}
+	})
+
+	t.Run("FrameTooLarge", func(t *testing.T) {
+		data := binary.BigEndian.AppendUint32(nil, netmerge.MaxFrameSize+1)
+		server := netmerge.NewServer(newSketch())
+		if err := server.ServeConn(bytes.NewReader(data)); !errors.Is(err, netmerge.ErrFrameTooLarge) {
+			t.Errorf("Expected ErrFrameTooLarge, got %v", err)
+		}
+	})
+}
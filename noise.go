@@ -0,0 +1,83 @@
+package topk
+
+import "math"
+
+// ExpectedNoiseInTopK returns the expected number of noise items in the top K of a sketch with the given parameters,
+// after counting `distinctNoise` distinct noise items with `noiseFreq` occurrences each.
+// This allows checking parameter choices analytically, e.g. how many of the K slots a stream's long tail claims
+// when it has fewer than K genuinely heavy items.
+//
+// The model assumes that
+//   - the sketch starts empty, and each noise item is added once with an increment of `noiseFreq` (as in `Add(item, noiseFreq)`),
+//   - the bucket indices of the items are independent and uniformly distributed in each row, and fingerprints don't collide,
+//   - a noise item is in the top K if it was admitted with a non-zero count, i.e. if it claimed or took over at least one of its buckets,
+//     and the top K holds the first K such items (later ones enter it only by a tie, see [WithTiePolicy], which doesn't change the number).
+//
+// It follows the distribution of a bucket's count across arrivals exactly under these assumptions,
+// taking into account that colliding items decay a bucket's count (with probability `decay^count` per unit of increment) until they take it over.
+// The number of noise items in the top K is then estimated as the smaller of K and the expected number of items admitted with a non-zero count.
+//
+// Computing the expectation takes O(noiseFreq³ + distinctNoise·noiseFreq²) time, so it is meant for offline use.
+func ExpectedNoiseInTopK(k, width, depth int, decay float32, distinctNoise int, noiseFreq int) float64 {
+	if k <= 0 || width <= 0 || depth <= 0 || distinctNoise <= 0 || noiseFreq <= 0 {
+		return 0
+	}
+	f := noiseFreq
+
+	// transition[c][c2] is the probability that a bucket with count c has count c2 after a noise item arrives at it,
+	// and takeover[c] is the probability that the arriving item claims the bucket.
+	transition := make([][]float64, f+1)
+	takeover := make([]float64, f+1)
+	transition[0] = make([]float64, f+1)
+	transition[0][f] = 1
+	takeover[0] = 1
+	counts := make([]float64, f+1)
+	for c := 1; c <= f; c++ {
+		transition[c] = make([]float64, f+1)
+		clear(counts)
+		counts[c] = 1
+		for remaining := f; remaining > 0; remaining-- {
+			// Ascending order, so that each unit of increment decrements a count at most once.
+			for x := 1; x <= c; x++ {
+				if counts[x] == 0 {
+					continue
+				}
+				decremented := counts[x] * math.Pow(float64(decay), float64(x))
+				counts[x] -= decremented
+				if x == 1 {
+					transition[c][remaining] += decremented
+					takeover[c] += decremented
+				} else {
+					counts[x-1] += decremented
+				}
+			}
+		}
+		for x := 1; x <= c; x++ {
+			transition[c][x] += counts[x]
+		}
+	}
+
+	// bucket is the distribution of the count of a random bucket, next is its distribution after the next arrival.
+	bucket := make([]float64, f+1)
+	next := make([]float64, f+1)
+	bucket[0] = 1
+	p := 1 / float64(width)
+	var admitted float64
+	for range distinctNoise {
+		var claim float64
+		for c, q := range bucket {
+			claim += q * takeover[c]
+		}
+		admitted += 1 - math.Pow(1-claim, float64(depth))
+		for c := range next {
+			next[c] = (1 - p) * bucket[c]
+		}
+		for c, q := range bucket {
+			for c2, t := range transition[c] {
+				next[c2] += p * q * t
+			}
+		}
+		bucket, next = next, bucket
+	}
+	return min(float64(k), admitted)
+}
@@ -0,0 +1,62 @@
+package topk_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestExpectedNoiseInTopK(t *testing.T) {
+	testCases := []struct {
+		k, width, depth          int
+		decay                    float32
+		distinctNoise, noiseFreq int
+	}{
+		{k: 1000, width: 16, depth: 2, decay: 0.9, distinctNoise: 200, noiseFreq: 10},
+		{k: 1000, width: 32, depth: 3, decay: 0.9, distinctNoise: 500, noiseFreq: 20},
+		{k: 1000, width: 8, depth: 1, decay: 0.9, distinctNoise: 300, noiseFreq: 3},
+		{k: 1000, width: 64, depth: 2, decay: 0.9, distinctNoise: 1000, noiseFreq: 50},
+		{k: 1000, width: 10, depth: 2, decay: 0.99, distinctNoise: 500, noiseFreq: 5},
+		{k: 50, width: 16, depth: 2, decay: 0.5, distinctNoise: 300, noiseFreq: 2},
+	}
+	for _, tc := range testCases {
+		t.Run(fmt.Sprintf("K=%d_Width=%d_Depth=%d_Decay=%v_Noise=%dx%d", tc.k, tc.width, tc.depth, tc.decay, tc.distinctNoise, tc.noiseFreq), func(t *testing.T) {
+			expected := topk.ExpectedNoiseInTopK(tc.k, tc.width, tc.depth, tc.decay, tc.distinctNoise, tc.noiseFreq)
+
+			const runs = 20
+			r := rand.New(rand.NewPCG(1, 2))
+			var noiseInTopK int
+			for run := range runs {
+				sketch := topk.New(tc.k, topk.WithWidth(tc.width), topk.WithDepth(tc.depth), topk.WithDecay(tc.decay), topk.WithRand(r))
+				for i := range tc.distinctNoise {
+					sketch.Add(fmt.Sprintf("noise_item_%d_%d", run, i), uint32(tc.noiseFreq))
+				}
+				for _, item := range sketch.SortedSlice() {
+					if item.Count > 0 {
+						noiseInTopK++
+					}
+				}
+			}
+			actual := float64(noiseInTopK) / runs
+
+			if math.Abs(actual-expected) > 0.05*expected {
+				t.Errorf("expected about %.1f noise items in the top K, got %.1f", expected, actual)
+			}
+		})
+	}
+}
+
+func TestExpectedNoiseInTopK_Degenerate(t *testing.T) {
+	if actual := topk.ExpectedNoiseInTopK(10, 1024, 3, 0.9, 0, 50); actual != 0 {
+		t.Errorf("expected no noise without noise items, got %v", actual)
+	}
+	if actual := topk.ExpectedNoiseInTopK(10, 1024, 3, 0.9, 5, 50); math.Abs(actual-5) > 0.1 {
+		t.Errorf("expected all 5 noise items in a wide sketch's top K, got %v", actual)
+	}
+	if actual := topk.ExpectedNoiseInTopK(10, 1024, 3, 0.9, 1000, 50); actual != 10 {
+		t.Errorf("expected noise to fill the top K, got %v", actual)
+	}
+}
@@ -1,5 +1,13 @@
 package topk
 
+import (
+	"math/rand/v2"
+	"slices"
+
+	"github.com/keilerkonzept/topk/heap"
+	"github.com/keilerkonzept/topk/internal/bloom"
+)
+
 type Option func(*Sketch)
 
 // WithDepth sets the depth (number of hash functions) of a sketch.
@@ -8,10 +16,222 @@ func WithDepth(depth int) Option { return func(s *Sketch) { s.Depth = depth } }
 // WithWidth sets the width (number of counters per hash function) of a sketch.
 func WithWidth(width int) Option { return func(s *Sketch) { s.Width = width } }
 
+// WithRowWidths gives each row of a sketch its own width (number of counters), overriding [WithDepth] and [WithWidth].
+// The sketch's depth is the number of widths.
+//
+// Choosing pairwise coprime widths (e.g. 1021, 1024, 1031) makes items that collide in one row unlikely to collide in another,
+// which can improve accuracy for the same total number of counters.
+func WithRowWidths(widths []int) Option {
+	return func(s *Sketch) { s.RowWidths = slices.Clone(widths) }
+}
+
 // WithDecay sets the counter decay probability on collisions.
 func WithDecay(decay float32) Option { return func(s *Sketch) { s.Decay = decay } }
 
+// WithMaxIncrementPerAdd clamps the increment of each [Sketch.Add] (and its variants) to maxIncrement.
+//
+// This keeps a single pathological event from claiming buckets and a top-K slot with one huge increment.
+// It changes the counting semantics: large legitimate bursts are under-counted, since only `maxIncrement` of each increment is counted.
+// A maxIncrement of 0 disables clamping.
+func WithMaxIncrementPerAdd(maxIncrement uint32) Option {
+	return func(s *Sketch) { s.MaxIncrementPerAdd = maxIncrement }
+}
+
+// WithDecayFunc replaces the geometric decay curve `Decay^count` by a custom function of the counter's value:
+// on a collision, a counter with the value `count` is decremented with probability `f(count)`.
+// This allows decay curves that spare large counters less aggressively, e.g. for long-tailed distributions.
+//
+// The function must return a value in [0, 1]; values outside that range act like 0 or 1.
+// It bypasses the decay LUT (and the [WithDecay] parameter), so it costs a function call per decay computation instead of a table lookup.
+// The function is not serialized with the sketch.
+func WithDecayFunc(f func(count uint32) float32) Option {
+	return func(s *Sketch) { s.decayFunc = f }
+}
+
 // WithDecayLUTSize sets the decay look-up table size.
 func WithDecayLUTSize(n int) Option {
 	return func(s *Sketch) { s.DecayLUT = make([]float32, n) }
 }
+
+// WithLazyDecayLUT defers computing the decay look-up table entries until they are first needed on a collision.
+// This makes [New] cheaper for short-lived or lightly used sketches, at the cost of a branch per decay computation.
+func WithLazyDecayLUT() Option {
+	return func(s *Sketch) { s.LazyDecayLUT = true }
+}
+
+// WithQueryFilter enables a Bloom filter over the items in the top-K heap.
+//
+// The filter lets [Sketch.Query] reject most items that are not in the top K without a map lookup,
+// which speeds up query streams that are mostly negative.
+// It never causes false negatives: a positive filter result is always confirmed by the heap's index.
+// The filter costs an extra hash computation for each [Sketch.Add] of an item in the top K, and 2-4 bytes of memory per top-K slot.
+func WithQueryFilter() Option {
+	return func(s *Sketch) { s.queryFilter = &bloom.Filter{} }
+}
+
+// WithLastUpdateTracking makes the top-K heap items record the time of their last update (in [heap.Item.LastUpdateUnixNano]),
+// which enables time-based eviction via [Sketch.EvictIdle].
+func WithLastUpdateTracking() Option {
+	return func(s *Sketch) { s.TrackLastUpdate = true }
+}
+
+// WithRand sets the random number source used for counter decay and sampling.
+// By default, the global source of math/rand/v2 is used; a seeded source makes the sketch's behavior reproducible.
+// The source is not safe for concurrent use, and neither is the sketch.
+func WithRand(r *rand.Rand) Option {
+	return func(s *Sketch) { s.rand = r }
+}
+
+// WithSampling makes [Sketch.Add] (and [Sketch.Incr], [Sketch.AddExact]) count only a random fraction `rate` of the calls,
+// scaling each counted increment by `1/rate` so that the counts stay unbiased.
+// Calls that are not sampled only report whether the item is in the top K.
+//
+// This cuts the cost of counting very high-volume streams at the cost of accuracy:
+// the variance of a count grows by a factor of about `1/rate`, so low-frequency items are estimated poorly
+// and may enter or leave the top K by chance.
+// A rate of 0 or at least 1 disables sampling.
+func WithSampling(rate float32) Option {
+	return func(s *Sketch) { s.SamplingRate = rate }
+}
+
+// WithIgnoreEmptyKeys makes adding (or decrementing) the empty string a no-op that returns false,
+// so that empty tokens (usually a bug in the caller's tokenizer) never occupy buckets or the top K.
+func WithIgnoreEmptyKeys() Option {
+	return func(s *Sketch) { s.IgnoreEmptyKeys = true }
+}
+
+// WithOrderedIter makes [Sketch.Iter] yield the top K items in descending count order, like [Sketch.SortedSlice].
+// Each iteration then sorts a copy of the heap in O(K log K) time and allocates O(K) memory,
+// and the yielded pointers refer to the copy rather than to the heap.
+// By default, Iter yields the items in heap order without copying.
+func WithOrderedIter() Option {
+	return func(s *Sketch) { s.OrderedIter = true }
+}
+
+// WithLogItems sets the number of top items that are included when logging the sketch via [log/slog] (see [Sketch.LogValue]).
+// A negative number omits the items.
+func WithLogItems(n int) Option {
+	return func(s *Sketch) { s.LogItems = n }
+}
+
+// WithDecayEventTracking makes the top-K heap items count how often their counters have been decremented by colliding items
+// (in [heap.Item.DecayEvents]), e.g. for reproducing the sketch's error behavior in research.
+// Each decrement then costs an O(K) scan of the heap for the decremented counter's item.
+// An item's count starts at zero whenever it (re-)enters the heap.
+func WithDecayEventTracking() Option {
+	return func(s *Sketch) { s.TrackDecayEvents = true }
+}
+
+// WithLifetimeCounts makes the top-K heap items sum the increments of the Adds they receive while in the heap
+// (in [heap.Item.LifetimeCount]), alongside their decayed count estimates.
+// Unlike the estimates, lifetime counts never decrease through collisions; an item's lifetime count starts with the increment
+// of the Add that brings it into the heap, and is discarded when the item leaves the heap.
+// This allows reporting "trending now" (the count) alongside "all-time while tracked" (the lifetime count).
+func WithLifetimeCounts() Option {
+	return func(s *Sketch) { s.TrackLifetimeCounts = true }
+}
+
+// WithTiePolicy sets whether an item whose count equals the top-K cutoff (see [Sketch.CutoffCount]) enters the full top K,
+// replacing the lexically smallest of the items with the cutoff count. The default is [heap.TieReplace].
+func WithTiePolicy(policy heap.TiePolicy) Option {
+	return func(s *Sketch) { s.TiePolicy = policy }
+}
+
+// WithMinCount keeps items whose counts are below min out of the top K, even while it isn't full,
+// so that the sketch tracks only the heavy hitters above the threshold instead of filling the top K with noise.
+// An item in the top K whose count drops below min (e.g. through collisions or [Sketch.Decr]) is removed from it on its next update.
+// [Sketch.SortedSlice] and [Sketch.Iter] then return fewer than K items whenever fewer items reach the threshold,
+// just as they already omit items with a zero count.
+func WithMinCount(min uint32) Option {
+	return func(s *Sketch) { s.MinCount = min }
+}
+
+// WithHeapBucketSync makes [Sketch.Query] check the buckets of items in the top K,
+// and evict an item from the top K if all of its buckets have been taken over by other items.
+//
+// The heap caches each item's count as of its last update and doesn't decay, so without this option an item stays in the top K
+// (and [Sketch.Count] reports its cached count) until it is pushed out by a larger one, even after colliding items took over all its buckets.
+// The check costs Depth hash computations and bucket reads for each Query of an item in the top K,
+// and makes Query modify the sketch, so concurrent Queries must be synchronized like Adds.
+func WithHeapBucketSync() Option {
+	return func(s *Sketch) { s.HeapBucketSync = true }
+}
+
+// WithOnPromote sets a callback that is called with the item and its count whenever an item enters the top K,
+// e.g. to fire an alert. It is not called when the count of an item that is already in the top K is updated.
+//
+// The callback runs synchronously inside [Sketch.Add] (and the other methods that count items), so a slow callback slows down counting,
+// and it must not call the sketch's methods. Items re-entering the heap in [Sketch.RebuildHeap] are promoted again.
+func WithOnPromote(f func(item string, count uint32)) Option {
+	return func(s *Sketch) { s.onPromote = f }
+}
+
+// WithOnEvict sets a callback that is called with the item and its last count in the top K whenever an item is pushed out of the top K
+// by a new item (or drops below the [WithMinCount] threshold).
+//
+// Like the callback of [WithOnPromote], it runs synchronously inside [Sketch.Add].
+// It is not called for items that leave the top K through [Sketch.Remove], [Sketch.EvictIdle], [Sketch.Reset], or [Sketch.RebuildHeap].
+func WithOnEvict(f func(item string, count uint32)) Option {
+	return func(s *Sketch) { s.onEvict = f }
+}
+
+// WithColdStartDecay applies decay to the part of an increment above the threshold that claims an empty bucket.
+//
+// By default, the first item to hit an empty bucket claims it with its full increment, while every other item has to decay it first,
+// so a single large Add early on (e.g. while the sketch is warming up) can hold its buckets and the top K against items that arrive steadily later.
+// With this option, an empty bucket is claimed with at most `threshold` of the increment, and each further unit raises the claimed count
+// with probability `Decay^count`, just like each unit of a colliding item decays a counter.
+// This makes the count of a bucket claimed by a large increment grow only logarithmically with the increment,
+// at the cost of under-estimating legitimately large increments, which is why it is off by default.
+// With a decay of 0, claims are capped at the threshold. A threshold of 0 disables the option.
+// [Sketch.AddExact] still claims empty buckets with the full increment.
+func WithColdStartDecay(threshold uint32) Option {
+	return func(s *Sketch) { s.ColdStartThreshold = threshold }
+}
+
+// WithCountDecreaseDetection makes the sketch count (in [Sketch.CountDecreases]) the updates that lowered an item's count in the top-K heap.
+//
+// The heap caches each item's count as of its last update, while the buckets keep changing:
+// colliding items decay the item's counters or take over its buckets.
+// The next Add of the item then re-computes its count from the buckets, which can be lower than the cached count,
+// so the reported count of an item in the top K can go down although the item has only been added to.
+// A non-zero CountDecreases signals that counts have been lost to collisions in this way, e.g. because the sketch is too narrow.
+func WithCountDecreaseDetection() Option {
+	return func(s *Sketch) { s.DetectCountDecreases = true }
+}
+
+// WithSeed sets the seed of a sketch's fingerprint and bucket index hashes (see [FingerprintSeeded] and [BucketIndexSeeded]).
+// The default seed 0 gives the hashes of [Fingerprint] and [BucketIndex].
+//
+// Sketches with different seeds place the same items into different buckets, so their collisions are independent,
+// and counting a stream in two such sketches gives two independent estimates per item that can be combined for a tighter estimate:
+// since counts lost to decay make most estimates too low, the larger of the two is usually closer to the true count,
+// while the smaller one guards against the rarer over-estimates caused by fingerprint collisions.
+// Sketches can only be merged if they have the same seed. The seed has no effect on the hash functions set by [WithHasher].
+func WithSeed(seed uint32) Option {
+	return func(s *Sketch) { s.Seed = seed }
+}
+
+// WithHasher replaces the hash functions of a sketch: fingerprint computes an item's fingerprint (by default [Fingerprint]),
+// and bucketIndex computes the index in the sketch's buckets of an item's counter in the given row (by default [BucketIndex]),
+// which must be in `[row*width, (row+1)*width)`.
+// If the sketch has per-row widths (see [WithRowWidths]), bucketIndex is called with the row's width, and its result is shifted to the row's offset.
+//
+// This allows reproducing the bucket layout of another system, or using a faster hash on a specific platform.
+// Sketches that are merged or compared must use the same hash functions, which can't be checked.
+// The functions are not serialized: a decoded sketch uses the default hash functions unless the option is applied again.
+func WithHasher(fingerprint func(item string) uint32, bucketIndex func(item string, row, width int) int) Option {
+	return func(s *Sketch) {
+		s.fingerprintFunc = fingerprint
+		s.bucketIndexFunc = bucketIndex
+	}
+}
+
+// WithRoundingMode sets how counts are rounded when they are multiplied by a factor, by [Sketch.MergeScaled] and [Sketch.DecayCounts].
+// The default is [RoundFloor].
+//
+// The choice matters most for repeated scaling, e.g. aging a sketch by a factor of 0.9 every period:
+// rounding down loses up to one count per period, while rounding to the nearest integer tracks the exact trajectory more closely.
+func WithRoundingMode(mode RoundingMode) Option {
+	return func(s *Sketch) { s.Rounding = mode }
+}
@@ -0,0 +1,66 @@
+// Package prometheus exports the top K items and the size of a [topk.Sketch] as Prometheus metrics.
+//
+// A [NewCollector] for a sketch named "requests" emits these gauges on each scrape:
+//
+//	requests_count{item="..."}  estimated count of each top-K item
+//	requests_heap_items         number of items in the top-K heap
+//	requests_heap_capacity      K
+//	requests_size_bytes         size of the sketch in bytes, see [topk.Sketch.SizeBytes]
+package prometheus
+
+import (
+	"github.com/keilerkonzept/topk"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector is a [prometheus.Collector] for a sketch's metrics, see [NewCollector].
+type Collector struct {
+	Sketch *topk.Sketch
+
+	count        *prometheus.Desc
+	heapItems    *prometheus.Desc
+	heapCapacity *prometheus.Desc
+	sizeBytes    *prometheus.Desc
+}
+
+// NewCollector returns a collector that exports the sketch's top K items and size as gauges whose names start with `name`.
+//
+// At most K item series are exported per scrape, so the cardinality of the item label is bounded by K
+// (series of items that have left the top K disappear from the next scrape).
+// Each scrape copies the top K via [topk.Sketch.SortedSlice], but the sketch is not safe for concurrent use:
+// if it is counted into while being scraped, the caller must synchronize the two, e.g. by collecting from a snapshot
+// (see [topk.Sketch.Clone] and [topk.SwappableSketch]).
+func NewCollector(name string, s *topk.Sketch) prometheus.Collector {
+	return &Collector{
+		Sketch:       s,
+		count:        prometheus.NewDesc(name+"_count", "Estimated count of a top-K item.", []string{"item"}, nil),
+		heapItems:    prometheus.NewDesc(name+"_heap_items", "Number of items in the top-K heap.", nil, nil),
+		heapCapacity: prometheus.NewDesc(name+"_heap_capacity", "Capacity K of the top-K heap.", nil, nil),
+		sizeBytes:    prometheus.NewDesc(name+"_size_bytes", "Size of the sketch in bytes.", nil, nil),
+	}
+}
+
+// Describe implements [prometheus.Collector].
+func (me *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- me.count
+	ch <- me.heapItems
+	ch <- me.heapCapacity
+	ch <- me.sizeBytes
+}
+
+// Collect implements [prometheus.Collector].
+func (me *Collector) Collect(ch chan<- prometheus.Metric) {
+	items := me.Sketch.SortedSlice()
+	used, capacity, _ := me.Sketch.HeapStats()
+	sizeBytes := me.Sketch.SizeBytes()
+
+	if len(items) > capacity {
+		items = items[:capacity]
+	}
+	for _, item := range items {
+		ch <- prometheus.MustNewConstMetric(me.count, prometheus.GaugeValue, float64(item.Count), item.Item)
+	}
+	ch <- prometheus.MustNewConstMetric(me.heapItems, prometheus.GaugeValue, float64(used))
+	ch <- prometheus.MustNewConstMetric(me.heapCapacity, prometheus.GaugeValue, float64(capacity))
+	ch <- prometheus.MustNewConstMetric(me.sizeBytes, prometheus.GaugeValue, float64(sizeBytes))
+}
@@ -0,0 +1,41 @@
+package prometheus_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+	topkprometheus "github.com/keilerkonzept/topk/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	sketch := topk.New(2, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	sketch.Add("c", 1)
+	collector := topkprometheus.NewCollector("requests", sketch)
+
+	expected := fmt.Sprintf(`
+# HELP requests_count Estimated count of a top-K item.
+# TYPE requests_count gauge
+requests_count{item="a"} 10
+requests_count{item="b"} 5
+# HELP requests_heap_capacity Capacity K of the top-K heap.
+# TYPE requests_heap_capacity gauge
+requests_heap_capacity 2
+# HELP requests_heap_items Number of items in the top-K heap.
+# TYPE requests_heap_items gauge
+requests_heap_items 2
+# HELP requests_size_bytes Size of the sketch in bytes.
+# TYPE requests_size_bytes gauge
+requests_size_bytes %d
+`, sketch.SizeBytes())
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "requests_count"); n != sketch.K {
+		t.Errorf("Expected %d item series, got %d", sketch.K, n)
+	}
+}
@@ -0,0 +1,67 @@
+package topk
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// UnknownItem returns the placeholder item string that [Sketch.RebuildHeap] uses for a fingerprint whose item is unknown.
+func UnknownItem(fingerprint uint32) string {
+	return fmt.Sprintf("<unknown:%08x>", fingerprint)
+}
+
+// RebuildHeap re-populates the top-K heap from the buckets:
+// it groups all buckets by fingerprint and keeps the K fingerprints with the highest counts.
+// Ties for the last slots are resolved deterministically, independent of the heap's previous order.
+// This refreshes the top K after operations that modified the buckets directly,
+// such as [Sketch.UnmarshalBucketsOnly] or [Sketch.Merge].
+//
+// Item strings can't be recovered from the buckets, which only store fingerprints.
+// Fingerprints of items that are currently in the heap keep their item strings (and are counted from the item's own buckets);
+// all other fingerprints are stored under the placeholder [UnknownItem](fingerprint).
+// Counting the actual item later adds it to the heap under its own string, next to the placeholder,
+// until the placeholder is evicted.
+func (me *Sketch) RebuildHeap() {
+	known := make(map[uint32]string, len(me.Heap.Items))
+	for _, item := range me.Heap.Items {
+		known[item.Fingerprint] = item.Item
+	}
+
+	counts := make(map[uint32]uint32)
+	for _, b := range me.Buckets {
+		if b.Count == 0 {
+			continue
+		}
+		if _, ok := known[b.Fingerprint]; ok {
+			continue
+		}
+		counts[b.Fingerprint] = max(counts[b.Fingerprint], b.Count)
+	}
+
+	candidates := make([]heap.Item, 0, len(known)+len(counts))
+	for fingerprint, item := range known {
+		if count := me.bucketCount(item, fingerprint); count > 0 {
+			candidates = append(candidates, heap.Item{Fingerprint: fingerprint, Item: item, Count: count})
+		}
+	}
+	for fingerprint, count := range counts {
+		candidates = append(candidates, heap.Item{Fingerprint: fingerprint, Item: UnknownItem(fingerprint), Count: count})
+	}
+
+	// Offer the candidates in a stable order (highest counts first), so that ties for the last slots
+	// are resolved the same way every time, independent of the map iteration order.
+	slices.SortFunc(candidates, func(a, b heap.Item) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Item, b.Item), cmp.Compare(a.Fingerprint, b.Fingerprint))
+	})
+
+	me.Heap.Reset()
+	for _, c := range candidates {
+		me.Heap.Update(c.Item, c.Fingerprint, c.Count)
+	}
+	if me.queryFilter != nil {
+		me.rebuildQueryFilter()
+	}
+}
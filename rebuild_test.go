@@ -0,0 +1,83 @@
+package topk_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+func TestSketch_RebuildHeap(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3))
+	counts := map[string]uint32{"a": 50, "b": 40, "c": 30, "d": 20, "e": 10}
+	for item, count := range counts {
+		sketch.Add(item, count)
+	}
+	expected := sketch.SortedSlice()
+
+	sketch.RebuildHeap()
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSketch_RebuildHeap_AfterBucketsOnlyLoad(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3))
+	for i, item := range []string{"a", "b", "c", "d", "e"} {
+		sketch.Add(item, uint32(10*(5-i)))
+	}
+	for i := range 100 {
+		sketch.Incr(fmt.Sprintf("noise%d", i))
+	}
+
+	data, err := sketch.MarshalBucketsOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded topk.Sketch
+	if err := decoded.UnmarshalBucketsOnly(data); err != nil {
+		t.Fatal(err)
+	}
+	decoded.RebuildHeap()
+
+	var expected []heap.Item
+	for _, item := range sketch.SortedSlice() {
+		expected = append(expected, heap.Item{Fingerprint: item.Fingerprint, Item: topk.UnknownItem(item.Fingerprint), Count: item.Count})
+	}
+	if diff := cmp.Diff(expected, decoded.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSketch_RebuildHeap_Ties(t *testing.T) {
+	// Five items tie for the last two slots; the chosen items must not depend on the order of the previous heap.
+	var expected []heap.Item
+	for i := range 20 {
+		sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+		sketch.Add("top", 100)
+		for _, j := range rand.Perm(5) {
+			sketch.Add(fmt.Sprintf("tie%d", j), 10)
+		}
+		data, err := sketch.MarshalBucketsOnly()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded topk.Sketch
+		if err := decoded.UnmarshalBucketsOnly(data); err != nil {
+			t.Fatal(err)
+		}
+		decoded.RebuildHeap()
+
+		actual := decoded.SortedSlice()
+		if i == 0 {
+			expected = actual
+			continue
+		}
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Fatalf("RebuildHeap chose different items for ties:\n%s", diff)
+		}
+	}
+}
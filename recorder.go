@@ -0,0 +1,51 @@
+package topk
+
+import (
+	"fmt"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// Recorder keeps the last few top-K snapshots of a sketch in a ring buffer, e.g. for post-mortem analysis
+// of how the leaderboard evolved leading up to an incident. Snapshots are taken on demand by [Recorder.Capture].
+type Recorder struct {
+	Sketch *Sketch       // Recorded sketch.
+	Ring   [][]heap.Item // Snapshots; once the ring is full, Ring[Next] is the oldest one.
+	Next   int           // Index in Ring of the next snapshot to overwrite.
+	Size   int           // Maximum number of snapshots.
+}
+
+// NewRecorder returns a recorder that keeps the last `size` snapshots of the sketch's top K.
+// Panics if size is negative.
+func NewRecorder(sketch *Sketch, size int) *Recorder {
+	if size < 0 {
+		panic(fmt.Sprintf("topk: NewRecorder: negative size %d", size))
+	}
+	return &Recorder{
+		Sketch: sketch,
+		Ring:   make([][]heap.Item, 0, size),
+		Size:   size,
+	}
+}
+
+// Capture stores a snapshot of the sketch's current top K (see [Sketch.SortedSlice]), replacing the oldest one if the ring is full.
+func (me *Recorder) Capture() {
+	if me.Size == 0 {
+		return
+	}
+	snapshot := me.Sketch.SortedSlice()
+	if len(me.Ring) < me.Size {
+		me.Ring = append(me.Ring, snapshot)
+		return
+	}
+	me.Ring[me.Next] = snapshot
+	me.Next = (me.Next + 1) % me.Size
+}
+
+// Snapshots returns the stored snapshots, oldest first.
+// The returned slice is new, but the snapshots themselves are shared with the recorder and must not be modified.
+func (me *Recorder) Snapshots() [][]heap.Item {
+	out := make([][]heap.Item, 0, len(me.Ring))
+	out = append(out, me.Ring[me.Next:]...)
+	return append(out, me.Ring[:me.Next]...)
+}
@@ -0,0 +1,58 @@
+package topk_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestRecorder(t *testing.T) {
+	sketch := topk.New(2, topk.WithWidth(1024), topk.WithDecay(0))
+	recorder := topk.NewRecorder(sketch, 3)
+	if n := len(recorder.Snapshots()); n != 0 {
+		t.Errorf("Expected no snapshots before the first capture, got %d", n)
+	}
+
+	leaders := func() [][]string {
+		var out [][]string
+		for _, snapshot := range recorder.Snapshots() {
+			var items []string
+			for _, item := range snapshot {
+				items = append(items, item.Item)
+			}
+			out = append(out, items)
+		}
+		return out
+	}
+
+	sketch.Add("a", 10)
+	recorder.Capture()
+	sketch.Add("b", 20)
+	recorder.Capture()
+	if diff := cmp.Diff([][]string{{"a"}, {"b", "a"}}, leaders()); diff != "" {
+		t.Errorf("Snapshots mismatch (-want +got):\n%s", diff)
+	}
+
+	sketch.Add("c", 30)
+	recorder.Capture()
+	sketch.Add("a", 30)
+	recorder.Capture()
+	sketch.Add("d", 50)
+	recorder.Capture()
+	expected := [][]string{{"c", "b"}, {"a", "c"}, {"d", "a"}}
+	if diff := cmp.Diff(expected, leaders()); diff != "" {
+		t.Errorf("Snapshots mismatch after wrapping around (-want +got):\n%s", diff)
+	}
+}
+
+func TestNewRecorder_NegativeSize(t *testing.T) {
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(fmt.Sprint(r), "negative size") {
+			t.Errorf("Expected NewRecorder to panic on a negative size, got %v", r)
+		}
+	}()
+	topk.NewRecorder(topk.New(3), -1)
+}
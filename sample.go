@@ -0,0 +1,37 @@
+package topk
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// WeightedSample draws n items from the top K with replacement, each with probability proportional to its count,
+// e.g. for assigning traffic to A/B buckets in proportion to popularity.
+// The draws use the given random number source, or the global source of math/rand/v2 if r is nil.
+// It returns nil if n is not positive or the top K is empty.
+//
+// Each draw takes O(log K) time, after sorting the top K once.
+func (me *Sketch) WeightedSample(n int, r *rand.Rand) []string {
+	items := me.SortedSlice()
+	if n <= 0 || len(items) == 0 {
+		return nil
+	}
+	// cumulative[i] is the total count of items[:i+1].
+	cumulative := make([]uint64, len(items))
+	var total uint64
+	for i, item := range items {
+		total += uint64(item.Count)
+		cumulative[i] = total
+	}
+	draw := rand.Uint64N
+	if r != nil {
+		draw = r.Uint64N
+	}
+	out := make([]string, n)
+	for i := range out {
+		// The first item whose cumulative count exceeds x covers x, so each item is hit by `Count` of the `total` values of x.
+		j, _ := slices.BinarySearch(cumulative, draw(total)+1)
+		out[i] = items[j].Item
+	}
+	return out
+}
@@ -0,0 +1,46 @@
+package topk_test
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_WeightedSample(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	if sample := sketch.WeightedSample(10, nil); sample != nil {
+		t.Errorf("Expected no sample from an empty sketch, got %v", sample)
+	}
+	counts := map[string]uint32{"a": 50, "b": 30, "c": 15, "d": 5}
+	var total uint32
+	for item, count := range counts {
+		sketch.Add(item, count)
+		total += count
+	}
+
+	const draws = 100_000
+	sample := sketch.WeightedSample(draws, rand.New(rand.NewPCG(1, 2)))
+	if len(sample) != draws {
+		t.Fatalf("Expected %d draws, got %d", draws, len(sample))
+	}
+	frequencies := make(map[string]int)
+	for _, item := range sample {
+		frequencies[item]++
+	}
+	for item, count := range counts {
+		expected := float64(count) / float64(total)
+		actual := float64(frequencies[item]) / draws
+		if math.Abs(actual-expected) > 0.01 {
+			t.Errorf("Expected item %s to be drawn with frequency %.3f, got %.3f", item, expected, actual)
+		}
+	}
+	if len(frequencies) != len(counts) {
+		t.Errorf("Expected only items in the top K to be drawn, got %v", frequencies)
+	}
+
+	if sample := sketch.WeightedSample(0, nil); sample != nil {
+		t.Errorf("Expected no sample for n = 0, got %v", sample)
+	}
+}
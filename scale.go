@@ -0,0 +1,75 @@
+package topk
+
+import (
+	"fmt"
+	"math"
+)
+
+// RoundingMode selects how counts that are multiplied by a factor (e.g. by [Sketch.MergeScaled] or [Sketch.DecayCounts]) are rounded to integers.
+type RoundingMode int
+
+const (
+	// RoundFloor rounds scaled counts down. This is the default: like the sketch itself, it never over-estimates,
+	// but repeated scaling loses up to one count per step, so small counts age out faster than the factor alone implies.
+	RoundFloor RoundingMode = iota
+	// RoundNearest rounds scaled counts to the nearest integer (halves away from zero), which is unbiased on average.
+	RoundNearest
+	// RoundCeil rounds scaled counts up. Counts then never age out by scaling alone, since a non-zero count stays at least 1.
+	RoundCeil
+)
+
+// round rounds x according to the rounding mode.
+func (m RoundingMode) round(x float64) float64 {
+	// Scaling factors are float32s, whose representation errors would otherwise make e.g. 100*0.9 round down to 89.
+	if r := math.Round(x); math.Abs(x-r) <= x*0x1p-23 {
+		return r
+	}
+	switch m {
+	case RoundNearest:
+		return math.Round(x)
+	case RoundCeil:
+		return math.Ceil(x)
+	default:
+		return math.Floor(x)
+	}
+}
+
+// scaleCount multiplies the count by the factor, rounding according to the sketch's rounding mode and saturating at [math.MaxUint32].
+func (me *Sketch) scaleCount(count uint32, factor float32) uint32 {
+	if factor == 1 {
+		return count
+	}
+	return uint32(min(me.Rounding.round(float64(count)*float64(factor)), math.MaxUint32))
+}
+
+// scaleTotal multiplies the total by the factor, rounding according to the sketch's rounding mode.
+func (me *Sketch) scaleTotal(total uint64, factor float32) uint64 {
+	if factor == 1 {
+		return total
+	}
+	return uint64(min(me.Rounding.round(float64(total)*float64(factor)), math.MaxUint64))
+}
+
+// DecayCounts multiplies all counts of the sketch (its buckets, its top-K heap, and its total) by the given factor,
+// rounding according to the sketch's rounding mode (see [WithRoundingMode]).
+// Items whose heap count drops to zero are removed from the top K.
+//
+// This ages the sketch's counts, e.g. with a factor of 0.5 at the end of each counting period,
+// so that recent events weigh more than old ones without discarding the old counts outright.
+// It is unrelated to the sketch's Decay parameter, which controls how colliding items decrement each other's counters.
+func (me *Sketch) DecayCounts(factor float32) error {
+	if factor < 0 || math.IsNaN(float64(factor)) {
+		return fmt.Errorf("topk: invalid decay factor %v", factor)
+	}
+	for i := range me.Buckets {
+		b := &me.Buckets[i]
+		b.Count = me.scaleCount(b.Count, factor)
+	}
+	for i := range me.Heap.Items {
+		item := &me.Heap.Items[i]
+		item.Count = me.scaleCount(item.Count, factor)
+	}
+	me.Heap.Reinit()
+	me.Total = me.scaleTotal(me.Total, factor)
+	return nil
+}
@@ -0,0 +1,58 @@
+package topk_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_DecayCounts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		mode     topk.RoundingMode
+		expected []uint32
+	}{
+		{"Floor", topk.RoundFloor, []uint32{90, 81, 72, 64, 57, 51, 45, 40}},
+		{"Nearest", topk.RoundNearest, []uint32{90, 81, 73, 66, 59, 53, 48, 43}},
+		{"Ceil", topk.RoundCeil, []uint32{90, 81, 73, 66, 60, 54, 49, 45}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3), topk.WithRoundingMode(tc.mode))
+			sketch.Add("a", 100)
+
+			var actual []uint32
+			for range tc.expected {
+				if err := sketch.DecayCounts(0.9); err != nil {
+					t.Fatal(err)
+				}
+				actual = append(actual, sketch.Count("a"))
+			}
+			if diff := cmp.Diff(tc.expected, actual); diff != "" {
+				t.Errorf("Count trajectory mismatch (-want +got):\n%s", diff)
+			}
+			if n := sketch.TotalCount(); uint32(n) != tc.expected[len(tc.expected)-1] {
+				t.Errorf("Expected TotalCount = %d, got %d", tc.expected[len(tc.expected)-1], n)
+			}
+		})
+	}
+}
+
+func TestSketch_DecayCounts_RemovesZeroCounts(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(256), topk.WithDepth(3))
+	sketch.Add("a", 10)
+	sketch.Add("b", 1)
+
+	if err := sketch.DecayCounts(0.5); err != nil {
+		t.Fatal(err)
+	}
+	if sketch.Query("b") {
+		t.Error("Expected b to leave the top K once its count is rounded down to 0")
+	}
+	if c := sketch.Count("a"); c != 5 {
+		t.Errorf("Expected Count(a) = 5, got %d", c)
+	}
+	if err := sketch.DecayCounts(-1); err == nil {
+		t.Error("Expected an error for a negative factor")
+	}
+}
@@ -1,15 +1,23 @@
 package topk
 
 import (
+	"cmp"
+	"fmt"
+	"iter"
+	"maps"
 	"math"
 	"math/rand/v2"
 	"slices"
-	"sort"
+	"strings"
+	"time"
 
 	"github.com/keilerkonzept/topk/heap"
+	"github.com/keilerkonzept/topk/internal/bloom"
 	"github.com/keilerkonzept/topk/internal/sizeof"
 )
 
+const defaultDecayLUTSize = 256
+
 // Bucket is a single sketch counter together with the corresponding item's fingerprint.
 type Bucket struct {
 	Fingerprint uint32
@@ -18,18 +26,68 @@ type Bucket struct {
 
 // Sketch is a top-k sketch.
 // The entire structure is serializable using any serialization method - all fields and sub-structs are exported and can be reasonably serialized.
+// For reporting only the sorted top K as JSON, see [Sketch.TopKJSON].
 type Sketch struct {
 	K     int // Keep track of top `K` items in the min-heap..
-	Width int // Number of buckets per hash function.
+	Width int // Number of buckets per hash function (the largest row width if RowWidths is set).
 	Depth int // Number of hash functions.
 
+	RowWidths  []int // Optional per-row numbers of buckets, see [WithRowWidths]. Nil if every row has Width buckets.
+	RowOffsets []int // RowOffsets[i] is the index in Buckets of row i's first bucket. Nil if RowWidths is nil.
+
+	Seed uint32 // Hash seed, see [WithSeed].
+
 	// `math.Pow(Decay, i)` is the probability that a flow's counter with value `i` is decremented on collision.
 	Decay float32
 	// Look-up table for powers of `Decay`. The value at `i` is `math.Pow(Decay, i)`
 	DecayLUT []float32
+	// If true, DecayLUT entries are computed on first use instead of in [New], see [WithLazyDecayLUT].
+	LazyDecayLUT bool
+
+	// If non-zero, the increment of each Add is clamped to MaxIncrementPerAdd, see [WithMaxIncrementPerAdd].
+	MaxIncrementPerAdd uint32
+	// If true, heap items record the time of their last update, see [WithLastUpdateTracking].
+	TrackLastUpdate bool
+	// If true, heap items count the decrements of their counters by colliding items, see [WithDecayEventTracking].
+	TrackDecayEvents bool
+	// If true, heap items sum the increments they receive while in the heap, see [WithLifetimeCounts].
+	TrackLifetimeCounts bool
+	// If true, CountDecreases counts the Adds that lowered an item's heap count, see [WithCountDecreaseDetection].
+	DetectCountDecreases bool
+	// If in (0, 1), only this fraction of Add calls is counted, see [WithSampling].
+	SamplingRate float32
+	// If true, adding the empty string is a no-op, see [WithIgnoreEmptyKeys].
+	IgnoreEmptyKeys bool
+	// If true, [Sketch.Iter] yields the items in descending count order, see [WithOrderedIter].
+	OrderedIter bool
+	// Number of top items included by [Sketch.LogValue], see [WithLogItems].
+	LogItems int
+	// Rounding of scaled counts, see [WithRoundingMode].
+	Rounding RoundingMode
+	// Admission of new items tied with the top-K cutoff, see [WithTiePolicy].
+	TiePolicy heap.TiePolicy
+	// Items with smaller counts are never in the top K, see [WithMinCount].
+	MinCount uint32
+	// If true, [Sketch.Query] evicts top-K items that no longer hold any bucket, see [WithHeapBucketSync].
+	HeapBucketSync bool
+	// If non-zero, empty buckets are claimed with at most this much of an increment without decay, see [WithColdStartDecay].
+	ColdStartThreshold uint32
 
 	Buckets []Bucket  // Sketch counters.
 	Heap    *heap.Min // Top-K min-heap.
+
+	Total          uint64 // Sum of all counted increments since the sketch was created or last reset, see [Sketch.TotalCount].
+	CountDecreases uint64 // Number of Adds that lowered an item's heap count, if DetectCountDecreases is set.
+
+	rand               *rand.Rand                            // Random number source, see [WithRand]. Nil means the global source.
+	queryFilter        *bloom.Filter                         // Optional Bloom filter over the heap's items, see [WithQueryFilter].
+	queryFilterInserts int                                   // Number of items added to the query filter since it was last rebuilt.
+	fingerprintFunc    func(item string) uint32              // Fingerprint hash, see [WithHasher]. Nil means [Fingerprint].
+	bucketIndexFunc    func(item string, row, width int) int // Bucket index hash, see [WithHasher]. Nil means [BucketIndex].
+	decayFunc          func(count uint32) float32            // Decay probability, see [WithDecayFunc]. Nil means the DecayLUT.
+	snapshot           map[string]rankedCount                // Baseline of [Sketch.ChangedSince]. Nil before the first snapshot.
+	onPromote          func(item string, count uint32)       // Callback for items entering the top K, see [WithOnPromote].
+	onEvict            func(item string, count uint32)       // Callback for items leaving the top K, see [WithOnEvict].
 }
 
 // New returns a sliding top-k sketch with the given `k` (number of top items to keep) and `windowSize` (in ticks).`
@@ -38,6 +96,7 @@ type Sketch struct {
 //   - The width defaults to `max(256, k*log(k))` unless the [WithWidth] option is set.
 //   - The decay parameter defaults to 0.9 unless the [WithDecay] option is set.
 //   - The decay LUT size defaults to 256 unless the [WithDecayLUTSize] option is set.
+//   - The [WithRowWidths] option overrides both the depth and the width.
 func New(k int, opts ...Option) *Sketch {
 	log_k := int(math.Log(float64(k)))
 	k_log_k := int(float64(k) * math.Log(float64(k)))
@@ -56,16 +115,31 @@ func New(k int, opts ...Option) *Sketch {
 
 	if len(out.DecayLUT) == 0 {
 		// if not specified, default to 256
-		out.DecayLUT = make([]float32, 256)
+		out.DecayLUT = make([]float32, defaultDecayLUTSize)
 	}
 
-	out.Heap = heap.NewMin(out.K)
+	out.Heap = out.newHeap()
+	if out.queryFilter != nil {
+		out.queryFilter = bloom.New(out.K)
+	}
 	out.initBuckets()
-	out.initDecayLUT()
+	if !out.LazyDecayLUT {
+		out.initDecayLUT()
+	}
 
 	return &out
 }
 
+// newHeap returns an empty top-K heap with the sketch's tie policy, minimum count, and callbacks.
+func (me *Sketch) newHeap() *heap.Min {
+	h := heap.NewMin(me.K)
+	h.TiePolicy = me.TiePolicy
+	h.MinCount = me.MinCount
+	h.OnPromote = me.onPromote
+	h.OnEvict = me.onEvict
+	return h
+}
+
 func (me *Sketch) initDecayLUT() {
 	for i := range me.DecayLUT {
 		me.DecayLUT[i] = float32(math.Pow(float64(me.Decay), float64(i)))
@@ -73,14 +147,113 @@ func (me *Sketch) initDecayLUT() {
 }
 
 func (me *Sketch) initBuckets() {
-	me.Buckets = make([]Bucket, me.Width*me.Depth)
+	if me.RowWidths == nil {
+		me.Buckets = make([]Bucket, me.Width*me.Depth)
+		return
+	}
+	me.Depth = len(me.RowWidths)
+	me.Width = slices.Max(me.RowWidths)
+	me.RowOffsets = make([]int, me.Depth)
+	numBuckets := 0
+	for i, w := range me.RowWidths {
+		me.RowOffsets[i] = numBuckets
+		numBuckets += w
+	}
+	me.Buckets = make([]Bucket, numBuckets)
+}
+
+// bucketIndex returns the index in Buckets of the item's bucket in the given row.
+func (me *Sketch) bucketIndex(item string, row int) int {
+	return bucketIndexWith(me.bucketIndexFunc, me.Seed, item, row, me.Width, me.RowWidths, me.RowOffsets)
+}
+
+// fingerprint returns the item's fingerprint.
+func (me *Sketch) fingerprint(item string) uint32 {
+	return fingerprintWith(me.fingerprintFunc, me.Seed, item)
+}
+
+// Hasher returns the sketch's fingerprint and bucket index hash functions, see [WithHasher].
+// These are [FingerprintSeeded] and [BucketIndexSeeded] with the sketch's seed unless the sketch was created with another hasher.
+func (me *Sketch) Hasher() (fingerprint func(item string) uint32, bucketIndex func(item string, row, width int) int) {
+	fingerprint, bucketIndex = me.fingerprintFunc, me.bucketIndexFunc
+	seed := me.Seed
+	if fingerprint == nil {
+		fingerprint = func(item string) uint32 { return FingerprintSeeded(item, seed) }
+	}
+	if bucketIndex == nil {
+		bucketIndex = func(item string, row, width int) int { return BucketIndexSeeded(item, row, width, seed) }
+	}
+	return fingerprint, bucketIndex
+}
+
+// Clone returns a deep copy of the sketch that shares no buckets, heap, or look-up tables with it,
+// e.g. for serving a snapshot from a read replica while the original keeps counting.
+// The clone shares the random source set by [WithRand], the hash functions set by [WithHasher], and the decay function set by [WithDecayFunc] with the original.
+func (me *Sketch) Clone() *Sketch {
+	out := *me
+	out.RowWidths = slices.Clone(me.RowWidths)
+	out.RowOffsets = slices.Clone(me.RowOffsets)
+	out.DecayLUT = slices.Clone(me.DecayLUT)
+	out.Buckets = slices.Clone(me.Buckets)
+	out.Heap = me.Heap.Clone()
+	out.snapshot = maps.Clone(me.snapshot)
+	if me.queryFilter != nil {
+		out.queryFilter = &bloom.Filter{Words: slices.Clone(me.queryFilter.Words), Mask: me.queryFilter.Mask}
+	}
+	return &out
+}
+
+// Equal returns whether the sketches have the same parameters, buckets, top-K heap items (in any heap order), and total count,
+// e.g. for checking that a sketch survives an encoding round trip.
+//
+// It compares the fields that affect counting and queries (including the seed, the increment clamp, sampling, rounding, tie policy, minimum count, heap-bucket sync, and cold-start threshold),
+// but ignores the decay LUT and row offsets, which are derived from the other fields,
+// the options that only affect bookkeeping or presentation (tracking flags, ordered iteration, logging), and the query filter.
+// The random sources, hash functions, and decay functions can't be compared.
+func (me *Sketch) Equal(other *Sketch) bool {
+	return me.K == other.K &&
+		me.Width == other.Width &&
+		me.Depth == other.Depth &&
+		slices.Equal(me.RowWidths, other.RowWidths) &&
+		me.Seed == other.Seed &&
+		me.Decay == other.Decay &&
+		me.MaxIncrementPerAdd == other.MaxIncrementPerAdd &&
+		me.SamplingRate == other.SamplingRate &&
+		me.IgnoreEmptyKeys == other.IgnoreEmptyKeys &&
+		me.Rounding == other.Rounding &&
+		me.TiePolicy == other.TiePolicy &&
+		me.MinCount == other.MinCount &&
+		me.HeapBucketSync == other.HeapBucketSync &&
+		me.ColdStartThreshold == other.ColdStartThreshold &&
+		me.Total == other.Total &&
+		slices.Equal(me.Buckets, other.Buckets) &&
+		me.Heap.Equal(other.Heap)
 }
 
 // SizeBytes returns the current size of the sketch in bytes.
 func (me *Sketch) SizeBytes() int {
-	bucketsSize := (sizeofBucketStruct) * len(me.Buckets)
-	heapSize := me.Heap.SizeBytes()
-	decayTableSize := len(me.DecayLUT) * sizeof.Float32
+	size := sizeBytes(len(me.Buckets), len(me.DecayLUT), me.Heap.SizeBytes())
+	size += (len(me.RowWidths) + len(me.RowOffsets)) * sizeof.Int
+	if me.queryFilter != nil {
+		size += me.queryFilter.SizeBytes()
+	}
+	return size
+}
+
+// EstimateSizeBytes returns the size in bytes that [Sketch.SizeBytes] reports for an empty sketch with the given parameters,
+// without allocating the sketch. A decayLUTSize of zero means the default LUT size used by [New].
+//
+// The size of a non-empty sketch additionally grows with the total length of the item strings in the top-K heap.
+func EstimateSizeBytes(k, width, depth, decayLUTSize int) int {
+	if decayLUTSize == 0 {
+		decayLUTSize = defaultDecayLUTSize
+	}
+	return sizeBytes(width*depth, decayLUTSize, heap.EstimateSizeBytes(k))
+}
+
+func sizeBytes(numBuckets, decayLUTSize, heapSize int) int {
+	bucketsSize := (sizeofBucketStruct) * numBuckets
+	decayTableSize := decayLUTSize * sizeof.Float32
 	return sizeofSketchStruct +
 		bucketsSize +
 		heapSize +
@@ -96,11 +269,47 @@ func (me *Sketch) Count(item string) uint32 {
 		}
 	}
 
-	fingerprint := Fingerprint(item)
+	return me.bucketCount(item, me.fingerprint(item))
+}
+
+// CountAndQuery returns the estimated count of the given item (see [Sketch.Count]) and whether it is in the top K (see [Sketch.Query]),
+// with a single heap lookup instead of one for each.
+func (me *Sketch) CountAndQuery(item string) (count uint32, inTopK bool) {
+	i := me.Heap.Find(item)
+	if i >= 0 && me.HeapBucketSync && !me.holdsBucket(item) {
+		me.Heap.Remove(item)
+		i = -1
+	}
+	if i >= 0 {
+		return me.Heap.Items[i].Count, true
+	}
+	return me.bucketCount(item, me.fingerprint(item)), false
+}
+
+// CountConfidence is like [Sketch.Count], but additionally returns how many of the Depth rows hold the item's fingerprint.
+// An estimate backed by fewer rows is less trustworthy, since some of the item's buckets have been claimed by other items.
+func (me *Sketch) CountConfidence(item string) (count uint32, rowsMatched int) {
+	fingerprint := me.fingerprint(item)
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint || b.Count == 0 {
+			continue
+		}
+		rowsMatched++
+		count = max(count, b.Count)
+	}
+	if i := me.Heap.Find(item); i >= 0 {
+		count = me.Heap.Items[i].Count
+	}
+	return count, rowsMatched
+}
+
+// bucketCount returns the maximum count among the item's buckets that hold its fingerprint.
+func (me *Sketch) bucketCount(item string, fingerprint uint32) uint32 {
 	var maxCount uint32
 
 	for i := range me.Depth {
-		b := &me.Buckets[BucketIndex(item, i, me.Width)]
+		b := &me.Buckets[me.bucketIndex(item, i)]
 		if b.Fingerprint != fingerprint {
 			continue
 		}
@@ -110,20 +319,168 @@ func (me *Sketch) Count(item string) uint32 {
 	return maxCount
 }
 
+// CountMinAcrossRows returns the minimum count among the item's buckets that hold its fingerprint, or 0 if none do.
+// It is a more conservative estimator than [Sketch.Count], which returns the maximum (or the heap's count for items in the top K).
+func (me *Sketch) CountMinAcrossRows(item string) uint32 {
+	fingerprint := me.fingerprint(item)
+	var minCount uint32
+	matched := false
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint {
+			continue
+		}
+		if !matched || b.Count < minCount {
+			minCount = b.Count
+		}
+		matched = true
+	}
+	return minCount
+}
+
+// CountSumAcrossRows returns the sum (saturating at [math.MaxUint32]) of the counts of the item's buckets that hold its fingerprint.
+// Since every row counts the item in full, the sum usually over-estimates the item's count by a factor of up to Depth;
+// it is meant for union-of-rows analyses, not as a drop-in replacement of [Sketch.Count] (the maximum) or [Sketch.CountMinAcrossRows] (the minimum).
+// For items outside the top K, `CountMinAcrossRows <= Count <= CountSumAcrossRows`.
+func (me *Sketch) CountSumAcrossRows(item string) uint32 {
+	fingerprint := me.fingerprint(item)
+	var sum uint32
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint {
+			continue
+		}
+		sum = addSaturating(sum, b.Count)
+	}
+	return sum
+}
+
+// countErrorFailureProbability is the probability with which the bound of [Sketch.CountWithError] may be exceeded.
+const countErrorFailureProbability = 0.01
+
+// CountWithError is like [Sketch.Count], but additionally returns a bound on how much the count under-estimates the item's true count:
+// with a probability of at least 99%, the true count is in `[count, count+maxUnderestimate]`.
+//
+// The bound follows the HeavyKeeper error analysis: in a single row of width w, a counter of an item with true count n
+// under-estimates n by at least εN (with N the total count) with a probability of at most 1/(ε·w·n·(b-1)), where b = 1/Decay.
+// The estimate is the maximum over the Depth independent rows, so all rows must under-estimate for the estimate to do so,
+// and solving `(1/(ε·w·n·(b-1)))^Depth = 1%` for εN gives the bound.
+// This assumes that
+//   - the item was counted with [Sketch.Add] or [Sketch.Incr] alone (see [Sketch.AddExact] and [Sketch.Decr]),
+//   - the count under-estimates the true count, which holds unless another item with the same fingerprint shares a bucket,
+//   - and the rows collide independently, which holds for the default hash functions.
+//
+// The estimated count stands in for the unknown true count n, which makes the bound conservative.
+// It is capped at the total count of the other items and is zero for a decay of 0, since counters then never decay.
+func (me *Sketch) CountWithError(item string) (count uint32, maxUnderestimate uint32) {
+	count = me.Count(item)
+	others := me.Total - min(me.Total, uint64(count))
+	if count == 0 {
+		return 0, uint32(min(others, math.MaxUint32))
+	}
+	if me.Decay == 0 {
+		return count, 0
+	}
+	width := me.Width
+	if me.RowWidths != nil {
+		width = slices.Min(me.RowWidths)
+	}
+	b := 1 / float64(me.Decay)
+	bound := float64(me.Total) / (float64(width) * float64(count) * (b - 1) * math.Pow(countErrorFailureProbability, 1/float64(me.Depth)))
+	return count, uint32(min(math.Ceil(bound), float64(min(others, math.MaxUint32))))
+}
+
 // Incr counts a single instance of the given item.
+// It is equivalent to `Add(item, 1)`, but faster, since a unit increment decays a colliding counter at most once.
 func (me *Sketch) Incr(item string) bool {
-	return me.Add(item, 1)
+	if me.SamplingRate > 0 && me.SamplingRate < 1 {
+		return me.Add(item, 1) // sampled increments are scaled beyond 1
+	}
+	if item == "" && me.IgnoreEmptyKeys {
+		return false
+	}
+	me.Total++
+	var maxCount uint32
+	fingerprint := me.fingerprint(item)
+
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		switch {
+		// empty bucket (zero count)
+		case b.Count == 0:
+			b.Fingerprint = fingerprint
+			b.Count = 1
+			maxCount = max(maxCount, 1)
+		// this flow's bucket (equal fingerprint)
+		case b.Fingerprint == fingerprint:
+			b.Count++
+			maxCount = max(maxCount, b.Count)
+		// another flow's bucket (nonequal fingerprint), decayed with probability `Decay^count`
+		case me.randFloat32() < me.decayProbability(b.Count):
+			if me.TrackDecayEvents {
+				me.recordDecayEvent(b.Fingerprint)
+			}
+			b.Count--
+			if b.Count == 0 {
+				b.Fingerprint = fingerprint
+				b.Count = 1
+				maxCount = max(maxCount, 1)
+			}
+		}
+	}
+
+	inTopK := me.updateHeap(item, fingerprint, maxCount)
+	me.recordLifetimeCount(item, inTopK, 1)
+	return inTopK
 }
 
 // Add increments the given item's count by the given increment.
 // Returns whether the item is in the top K.
 func (me *Sketch) Add(item string, increment uint32) bool {
+	return me.add(item, increment, false, false)
+}
+
+// AddAll adds the item with the given increment to each of the sketches, in the given order.
+// It is a single call site for fanning out counts, e.g. to sketches with different resolutions or to replicas;
+// there is no rollback, since counts can't be meaningfully removed from a sketch.
+func AddAll(item string, increment uint32, sketches ...*Sketch) {
+	for _, s := range sketches {
+		s.Add(item, increment)
+	}
+}
+
+// AddExact is like [Sketch.Add], but resolves collisions deterministically in favor of large increments:
+// if a bucket holds another item's fingerprint with a count smaller than the increment,
+// AddExact takes over the bucket with the difference, instead of decaying the counter probabilistically.
+// Smaller increments decay the counter as usual.
+//
+// This is meant for authoritative, high-value events (e.g. confirmed purchases mixed with page views) that must reliably register.
+// Mixing AddExact and Add on the same sketch mixes two counting semantics:
+// the under-estimation guarantees of HeavyKeeper then only hold for items that are counted with Add alone,
+// and items displaced by AddExact lose their counts outright.
+func (me *Sketch) AddExact(item string, increment uint32) bool {
+	return me.add(item, increment, true, false)
+}
+
+// add counts the item. If transient is true, the item string may be backed by memory that the caller reuses,
+// so it is copied before it is stored in the heap.
+func (me *Sketch) add(item string, increment uint32, exact, transient bool) bool {
+	if item == "" && me.IgnoreEmptyKeys {
+		return false
+	}
+	increment = me.clampIncrement(increment)
+	if me.SamplingRate > 0 && me.SamplingRate < 1 {
+		if me.randFloat32() >= me.SamplingRate {
+			return me.Query(item)
+		}
+		increment = me.scaleSampledIncrement(increment)
+	}
+	me.Total += uint64(increment)
 	var maxCount uint32
-	fingerprint := Fingerprint(item)
+	fingerprint := me.fingerprint(item)
 
-	width := me.Width
 	for i := range me.Depth {
-		k := BucketIndex(item, i, width)
+		k := me.bucketIndex(item, i)
 		b := &me.Buckets[k]
 		count := b.Count
 		switch {
@@ -131,6 +488,9 @@ func (me *Sketch) Add(item string, increment uint32) bool {
 		case count == 0:
 			b.Fingerprint = fingerprint
 			count = increment
+			if !exact {
+				count = me.claimCount(increment)
+			}
 			b.Count = count
 			maxCount = max(maxCount, count)
 		// this flow's bucket (equal fingerprint)
@@ -138,44 +498,319 @@ func (me *Sketch) Add(item string, increment uint32) bool {
 			count += increment
 			b.Count = count
 			maxCount = max(maxCount, count)
+		// another flow's bucket (nonequal fingerprint), taken over deterministically
+		case exact && increment > count:
+			b.Fingerprint = fingerprint
+			count = increment - count
+			b.Count = count
+			maxCount = max(maxCount, count)
 		// another flow's bucket (nonequal fingerprint)
 		default:
-			// can't be inlined, so not factored out
-			var decay float32
-			lookupTableSize := uint32(len(me.DecayLUT))
-			for incrementRemaining := increment; incrementRemaining > 0; incrementRemaining-- {
-				if count < lookupTableSize {
-					decay = me.DecayLUT[count]
-				} else {
-					decay =
-						float32(math.Pow(
-							float64(me.DecayLUT[lookupTableSize-1]),
-							float64(count/(lookupTableSize-1)))) * me.DecayLUT[count%(lookupTableSize-1)]
-				}
-				if rand.Float32() < decay {
-					count--
-					if count == 0 {
-						b.Fingerprint = fingerprint
-						count = incrementRemaining
-						maxCount = max(maxCount, count)
-						break
-					}
-				}
-			}
+			maxCount = max(maxCount, me.decayBucket(b, fingerprint, increment))
+		}
+	}
+
+	if transient && me.Heap.WouldInsert(item, maxCount) {
+		item = strings.Clone(item)
+	}
+	inTopK := me.updateHeap(item, fingerprint, maxCount)
+	me.recordLifetimeCount(item, inTopK, increment)
+	return inTopK
+}
+
+// Decr decrements the given item's count by the given decrement:
+// it subtracts the decrement (clamping at zero) from the item's buckets that hold its fingerprint,
+// and updates the item's count in the top-K heap from the decremented buckets, removing the item from the heap if its count drops to zero.
+// If none of the item's buckets hold its fingerprint, or if the item is the empty string and [WithIgnoreEmptyKeys] is set, Decr is a no-op.
+//
+// This is meant for retracting previously counted events (e.g. a cancelled order).
+// Decrements can't recover information lost to collisions: if a counter has decayed, or its bucket has been taken over by another item,
+// the item's count was already under-estimated, and decrementing it under-estimates it further.
+func (me *Sketch) Decr(item string, decrement uint32) {
+	if item == "" && me.IgnoreEmptyKeys {
+		return
+	}
+	fingerprint := me.fingerprint(item)
+	var maxCount, maxCountBefore uint32
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint || b.Count == 0 {
+			continue
+		}
+		maxCountBefore = max(maxCountBefore, b.Count)
+		b.Count -= min(b.Count, decrement)
+		maxCount = max(maxCount, b.Count)
+	}
+	if maxCountBefore == 0 {
+		return
+	}
+	me.Total -= min(me.Total, uint64(maxCountBefore-maxCount))
+
+	if maxCount == 0 {
+		me.Heap.Remove(item)
+		return
+	}
+	me.Heap.Fix(item, maxCount)
+}
+
+// Remove purges the item from the sketch, e.g. to honor a deletion request: it empties the item's buckets that hold its fingerprint,
+// subtracts the item's estimated count from the total count, and removes the item from the top-K heap.
+//
+// Removal is best-effort: buckets only store fingerprints, so an item that collides with the removed item in a bucket
+// and has the same fingerprint loses its count there as well, and the removed item's own counts that were lost to collisions earlier
+// (i.e. are held by other items' fingerprints) can't be found. The item's string also remains in the query filter, if any,
+// which only costs a map lookup for later queries.
+func (me *Sketch) Remove(item string) {
+	fingerprint := me.fingerprint(item)
+	var maxCount uint32
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint || b.Count == 0 {
+			continue
+		}
+		maxCount = max(maxCount, b.Count)
+		*b = Bucket{}
+	}
+	me.Total -= min(me.Total, uint64(maxCount))
+	me.Heap.Remove(item)
+}
+
+// AddPrecomputed is like [Sketch.Add], but takes the item's fingerprint and bucket indices (one per row) from the caller instead of hashing the item.
+// This allows pipelines that hash many items in bulk to skip re-hashing them in the sketch.
+//
+// The fingerprint must be [Fingerprint](item) and the i-th bucket index must be [BucketIndex](item, i, Width)
+// (or [RowBucketIndex](item, i, RowWidths, RowOffsets) if the sketch has per-row widths), or their counterparts from [Sketch.Hasher];
+// otherwise the item is counted in the wrong buckets.
+// Panics if the number of bucket indices is not equal to the sketch's depth.
+func (me *Sketch) AddPrecomputed(item string, increment uint32, fingerprint uint32, bucketIndices []int) bool {
+	if len(bucketIndices) != me.Depth {
+		panic(fmt.Sprintf("topk: AddPrecomputed: got %d bucket indices for a sketch of depth %d", len(bucketIndices), me.Depth))
+	}
+	if item == "" && me.IgnoreEmptyKeys {
+		return false
+	}
+
+	increment = me.clampIncrement(increment)
+	if me.SamplingRate > 0 && me.SamplingRate < 1 {
+		if me.randFloat32() >= me.SamplingRate {
+			return me.Query(item)
+		}
+		increment = me.scaleSampledIncrement(increment)
+	}
+	me.Total += uint64(increment)
+	var maxCount uint32
+	for _, k := range bucketIndices {
+		b := &me.Buckets[k]
+		count := b.Count
+		switch {
+		case count == 0:
+			b.Fingerprint = fingerprint
+			count = me.claimCount(increment)
 			b.Count = count
+			maxCount = max(maxCount, count)
+		case b.Fingerprint == fingerprint:
+			count += increment
+			b.Count = count
+			maxCount = max(maxCount, count)
+		default:
+			maxCount = max(maxCount, me.decayBucket(b, fingerprint, increment))
+		}
+	}
+
+	inTopK := me.updateHeap(item, fingerprint, maxCount)
+	me.recordLifetimeCount(item, inTopK, increment)
+	return inTopK
+}
+
+// claimCount returns the count of an empty bucket claimed with the given increment:
+// the increment itself, or with [WithColdStartDecay], the threshold plus the part of the increment above it that survives decay,
+// where each unit raises the count with probability `Decay^count`.
+func (me *Sketch) claimCount(increment uint32) uint32 {
+	if me.ColdStartThreshold == 0 || increment <= me.ColdStartThreshold {
+		return increment
+	}
+	count := me.ColdStartThreshold
+	for range increment - count {
+		if me.randFloat32() < me.decayProbability(count) {
+			count++
+		}
+	}
+	return count
+}
+
+func (me *Sketch) clampIncrement(increment uint32) uint32 {
+	if me.MaxIncrementPerAdd != 0 {
+		return min(increment, me.MaxIncrementPerAdd)
+	}
+	return increment
+}
+
+// scaleSampledIncrement scales the increment of a sampled Add by `1/SamplingRate`,
+// rounding randomly to one of the two nearest integers so that the expected scaled increment is exact.
+func (me *Sketch) scaleSampledIncrement(increment uint32) uint32 {
+	scaled := float64(increment) / float64(me.SamplingRate)
+	whole, frac := math.Modf(scaled)
+	if float64(me.randFloat32()) < frac {
+		whole++
+	}
+	return uint32(min(whole, math.MaxUint32))
+}
+
+func (me *Sketch) randFloat32() float32 {
+	if me.rand != nil {
+		return me.rand.Float32()
+	}
+	return rand.Float32()
+}
+
+// decayBucket counts the increment in a bucket holding another item's fingerprint,
+// decaying the bucket's counter with probability `Decay^count` for each unit of the increment.
+// If the counter reaches zero, the bucket is taken over with the remaining increment.
+// Returns the bucket's new count if it has been taken over, and zero otherwise.
+func (me *Sketch) decayBucket(b *Bucket, fingerprint, increment uint32) uint32 {
+	count := b.Count
+	for incrementRemaining := increment; incrementRemaining > 0; incrementRemaining-- {
+		if me.randFloat32() < me.decayProbability(count) {
+			if me.TrackDecayEvents {
+				me.recordDecayEvent(b.Fingerprint)
+			}
+			count--
+			if count == 0 {
+				b.Fingerprint = fingerprint
+				b.Count = incrementRemaining
+				return incrementRemaining
+			}
+		}
+	}
+	b.Count = count
+	return 0
+}
+
+// recordDecayEvent counts a decrement of a counter holding the given fingerprint for the heap item with that fingerprint, if any.
+// It scans the heap, since the heap is not indexed by fingerprint.
+func (me *Sketch) recordDecayEvent(fingerprint uint32) {
+	for i := range me.Heap.Items {
+		if me.Heap.Items[i].Fingerprint == fingerprint {
+			me.Heap.Items[i].DecayEvents++
+			return
+		}
+	}
+}
+
+// decayProbability returns `Decay^count`, the probability of decrementing a counter with the given value on collision,
+// or the value of the decay function set by [WithDecayFunc].
+func (me *Sketch) decayProbability(count uint32) float32 {
+	if me.decayFunc != nil {
+		return me.decayFunc(count)
+	}
+	lookupTableSize := uint32(len(me.DecayLUT))
+	if count < lookupTableSize {
+		return me.decayLUTEntry(count)
+	}
+	return float32(math.Pow(
+		float64(me.decayLUTEntry(lookupTableSize-1)),
+		float64(count/(lookupTableSize-1)))) * me.decayLUTEntry(count%(lookupTableSize-1))
+}
+
+// decayLUTEntry returns `DecayLUT[i]`, computing and caching it first if the LUT is lazy.
+// Zero marks entries that have not been computed yet; a zero power of `Decay` is simply recomputed on each use.
+func (me *Sketch) decayLUTEntry(i uint32) float32 {
+	decay := me.DecayLUT[i]
+	if decay == 0 && me.LazyDecayLUT {
+		decay = float32(math.Pow(float64(me.Decay), float64(i)))
+		me.DecayLUT[i] = decay
+	}
+	return decay
+}
+
+// updateHeap offers the item with the given count to the top-K heap, keeping the query filter up to date.
+func (me *Sketch) updateHeap(item string, fingerprint, count uint32) bool {
+	if me.DetectCountDecreases {
+		if b := me.Heap.Get(item); b != nil && count < b.Count {
+			me.CountDecreases++
 		}
 	}
+	inTopK := me.Heap.Update(item, fingerprint, count)
+	if inTopK && me.TrackLastUpdate {
+		me.Heap.Get(item).LastUpdateUnixNano = time.Now().UnixNano()
+	}
+	if inTopK && me.queryFilter != nil && !me.queryFilter.MayContain(item) {
+		me.queryFilter.Add(item)
+		me.queryFilterInserts++
+		// Items evicted from the heap remain in the filter, so rebuild it once they could make up the majority of its keys.
+		if me.queryFilterInserts > 2*me.K {
+			me.rebuildQueryFilter()
+		}
+	}
+	return inTopK
+}
+
+// recordLifetimeCount adds the increment to the item's lifetime count if the item is in the top K and lifetime counts are tracked.
+func (me *Sketch) recordLifetimeCount(item string, inTopK bool, increment uint32) {
+	if inTopK && me.TrackLifetimeCounts {
+		me.Heap.Get(item).LifetimeCount += uint64(increment)
+	}
+}
 
-	return me.Heap.Update(item, fingerprint, maxCount)
+func (me *Sketch) rebuildQueryFilter() {
+	me.queryFilter.Reset()
+	for i := range me.Heap.Items {
+		me.queryFilter.Add(me.Heap.Items[i].Item)
+	}
+	me.queryFilterInserts = len(me.Heap.Items)
 }
 
 // Query returns whether the given item is in the top K items by count.
+// With the [WithHeapBucketSync] option, it removes the item from the top K (and returns false) if none of its buckets hold its fingerprint.
 func (me *Sketch) Query(item string) bool {
-	return me.Heap.Contains(item)
+	if me.queryFilter != nil && !me.queryFilter.MayContain(item) {
+		return false
+	}
+	if !me.Heap.Contains(item) {
+		return false
+	}
+	if me.HeapBucketSync && !me.holdsBucket(item) {
+		me.Heap.Remove(item)
+		return false
+	}
+	return true
+}
+
+// holdsBucket returns whether any of the item's buckets holds its fingerprint with a non-zero count.
+func (me *Sketch) holdsBucket(item string) bool {
+	fingerprint := me.fingerprint(item)
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint == fingerprint && b.Count != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// CutoffCount returns the smallest count in the top K once it holds K items, and 0 while it is still filling up.
+// An item whose estimated count reaches the cutoff enters the top K on its next [Sketch.Add].
+func (me *Sketch) CutoffCount() uint32 {
+	if !me.Heap.Full() {
+		return 0
+	}
+	return me.Heap.Min()
+}
+
+// HeapStats returns the number of items in the top-K heap, its capacity K, and the smallest count in the heap (0 if it is empty).
+// A heap that is always full with a high minimum count suggests that K is too small for the stream, one that rarely fills up that K is too large.
+// Unlike [Sketch.CutoffCount], the minimum count is reported while the heap is still filling up.
+func (me *Sketch) HeapStats() (used, capacity int, minCount uint32) {
+	return me.Heap.Len(), me.K, me.Heap.Min()
 }
 
 // Iter iterates over the top K items.
+// The items are yielded in heap order, unless the [WithOrderedIter] option is set.
 func (me *Sketch) Iter(yield func(*heap.Item) bool) {
+	if me.OrderedIter {
+		me.SortedIter(yield)
+		return
+	}
 	for i := range me.Heap.Items {
 		if me.Heap.Items[i].Count == 0 {
 			continue
@@ -186,16 +821,161 @@ func (me *Sketch) Iter(yield func(*heap.Item) bool) {
 	}
 }
 
+// SortedIter iterates over the top K items in descending count order, with ties broken by item, exactly as in [Sketch.SortedSlice].
+// Unlike [Sketch.Iter] (without the [WithOrderedIter] option), which yields the heap's items in unspecified heap order,
+// the first yielded item always has the largest count.
+//
+// The items are sorted into a single internal copy, so the yielded pointers don't point into the heap, and modifying the items has no effect.
+// Stopping early saves the caller from building a second slice of the items, but not the sort.
+func (me *Sketch) SortedIter(yield func(*heap.Item) bool) {
+	items := me.SortedSlice()
+	for i := range items {
+		if !yield(&items[i]) {
+			break
+		}
+	}
+}
+
+// Seq returns an iterator over the top K items and their counts in the same order as [Sketch.Iter], skipping items with a zero count:
+//
+//	for item, count := range sketch.Seq() { ... }
+func (me *Sketch) Seq() iter.Seq2[string, uint32] {
+	return func(yield func(string, uint32) bool) {
+		me.Iter(func(item *heap.Item) bool {
+			return yield(item.Item, item.Count)
+		})
+	}
+}
+
+// TotalCount returns the sum of all increments counted since the sketch was created or last reset,
+// including those of items outside the top K and those lost to collisions.
+func (me *Sketch) TotalCount() uint64 {
+	return me.Total
+}
+
+// KForMass returns the number of top items whose counts sum up to at least the given fraction of [Sketch.TotalCount],
+// e.g. how many top items cover 80% of the traffic for a fraction of 0.8.
+// If the top K items don't cover the fraction, K (or the number of items in the top K, if fewer) is returned.
+func (me *Sketch) KForMass(fraction float64) int {
+	target := fraction * float64(me.Total)
+	items := me.SortedSlice()
+	var sum float64
+	for i, item := range items {
+		if sum >= target {
+			return i
+		}
+		sum += float64(item.Count)
+	}
+	return len(items)
+}
+
+// CountMoments returns the mean and the (population) standard deviation of the counts of the current top K items.
+// Both are zero if the sketch is empty.
+func (me *Sketch) CountMoments() (mean, stddev float64) {
+	var n, sum, sumSquares float64
+	for item := range me.Iter {
+		c := float64(item.Count)
+		n++
+		sum += c
+		sumSquares += c * c
+	}
+	if n == 0 {
+		return 0, 0
+	}
+	mean = sum / n
+	variance := max(0, sumSquares/n-mean*mean)
+	return mean, math.Sqrt(variance)
+}
+
+// TopK returns a copy of the current top-k items in descending count order.
+// It is equivalent to [Sketch.SortedSlice].
+func (me *Sketch) TopK() []heap.Item {
+	return sortedItems(me.Heap.Items)
+}
+
+// TopKInto is like [Sketch.TopK], but stores the items in buf, re-using its memory if it has enough capacity,
+// which avoids allocating in hot reporting loops. The previous contents of buf are overwritten.
+// A nil buf makes TopKInto behave like TopK.
+func (me *Sketch) TopKInto(buf []heap.Item) []heap.Item {
+	return sortedItemsInto(buf, me.Heap.Items)
+}
+
 // SortedSlice returns the top K items as a sorted slice.
 func (me *Sketch) SortedSlice() []heap.Item {
-	out := slices.Clone(me.Heap.Items)
+	return sortedItems(me.Heap.Items)
+}
+
+// SortedSliceActive is like [Sketch.SortedSlice], but only returns the items with a count of at least minCount.
+// This drops stale low-count entries, e.g. while the heap is warming up or after the counts have decayed.
+func (me *Sketch) SortedSliceActive(minCount uint32) []heap.Item {
+	out := me.SortedSlice()
+	end := len(out)
+	for ; end > 0; end-- {
+		if out[end-1].Count >= minCount {
+			break
+		}
+	}
+	return out[:end]
+}
+
+// Rank returns the 1-based position of the item in the top K in descending count order (with ties broken by item, as in [Sketch.SortedSlice]),
+// and whether the item is in the top K. It counts the items ranked before the item in O(K) time, without sorting.
+func (me *Sketch) Rank(item string) (rank int, ok bool) {
+	self := me.Heap.Get(item)
+	if self == nil || self.Count == 0 {
+		return 0, false
+	}
+	rank = 1
+	for _, other := range me.Heap.Items {
+		if other.Count > self.Count || other.Count == self.Count && other.Item < item {
+			rank++
+		}
+	}
+	return rank, true
+}
+
+// ItemsNear returns the top-K items whose counts are within `±tolerance` of the given count, in descending count order.
+// This helps investigating ties and near-ties in the leaderboard.
+func (me *Sketch) ItemsNear(count, tolerance uint32) []heap.Item {
+	low := count - min(count, tolerance)
+	high := count + min(math.MaxUint32-count, tolerance)
+	var out []heap.Item
+	for _, item := range me.Heap.Items {
+		if item.Count >= low && item.Count <= high {
+			out = append(out, item)
+		}
+	}
+	return sortedItems(out)
+}
+
+// ItemsAbove returns the top-K items whose counts are at least the given threshold, in descending count order,
+// e.g. for reporting every tracked item that exceeds an alerting threshold. It returns nil if there are none.
+// A threshold of 0 returns the same items as [Sketch.SortedSlice].
+func (me *Sketch) ItemsAbove(threshold uint32) []heap.Item {
+	var out []heap.Item
+	for _, item := range me.Heap.Items {
+		if item.Count >= threshold {
+			out = append(out, item)
+		}
+	}
+	return sortedItems(out)
+}
+
+// sortedItems returns a copy of the given heap items with non-zero counts, sorted by descending count and then by item.
+func sortedItems(items []heap.Item) []heap.Item {
+	return sortedItemsInto(nil, items)
+}
+
+// sortedItemsInto is like sortedItems, but copies the items into buf (growing it if needed) instead of a new slice.
+func sortedItemsInto(buf, items []heap.Item) []heap.Item {
+	out := append(buf[:0], items...)
 
-	sort.SliceStable(out, func(i, j int) bool {
-		ci, cj := out[i].Count, out[j].Count
-		if ci == cj {
-			return out[i].Item < out[j].Item
+	// Item strings are unique, so this order is total and an unstable sort is deterministic.
+	slices.SortFunc(out, func(a, b heap.Item) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
 		}
-		return ci > cj
+		return strings.Compare(a.Item, b.Item)
 	})
 
 	end := len(out)
@@ -208,8 +988,109 @@ func (me *Sketch) SortedSlice() []heap.Item {
 	return out[:end]
 }
 
+// ItemsByFingerprint returns the top K items ordered by fingerprint (and by item string for equal fingerprints).
+// Unlike [Sketch.SortedSlice], the order doesn't depend on the counts,
+// which makes dumps of the sketch's contents stable across runs, e.g. for golden-file tests.
+func (me *Sketch) ItemsByFingerprint() []heap.Item {
+	out := make([]heap.Item, 0, len(me.Heap.Items))
+	for item := range me.Iter {
+		out = append(out, *item)
+	}
+	slices.SortFunc(out, func(a, b heap.Item) int {
+		if c := cmp.Compare(a.Fingerprint, b.Fingerprint); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Item, b.Item)
+	})
+	return out
+}
+
+// Drain returns the top K items as a sorted slice (like [Sketch.SortedSlice]) and then resets the sketch to an empty state.
+func (me *Sketch) Drain() []heap.Item {
+	out := me.SortedSlice()
+	me.Reset()
+	return out
+}
+
 // Reset resets the sketch to an empty state.
 func (me *Sketch) Reset() {
 	clear(me.Buckets)
 	me.Heap.Reset()
+	me.resetStats()
+}
+
+// resetStats resets the bookkeeping derived from the buckets and the heap.
+// Every counter that [Sketch.Add] maintains besides the buckets and the heap must be reset here.
+func (me *Sketch) resetStats() {
+	me.Total = 0
+	me.CountDecreases = 0
+	if me.queryFilter != nil {
+		me.queryFilter.Reset()
+	}
+	me.queryFilterInserts = 0
+}
+
+// ResetBucketsKeepHeap zeroes all buckets but leaves the top-K heap intact,
+// so that the current leaders remain queryable with their counts at the start of a new counting period.
+//
+// If reseed is true, each heap item's count is written back into its buckets (in every row),
+// so that subsequent [Sketch.Add] calls continue counting from the preserved counts.
+// Re-seeding biases the new period in favor of the previous leaders:
+// they start with a head start over items first seen in the new period,
+// and where two leaders share a bucket, only the one with the larger count keeps it.
+// Without re-seeding, the heap keeps reporting the preserved counts until the leaders' new-period counts catch up.
+//
+// The total count (see [Sketch.TotalCount]), the count decrease counter, and the baseline of [Sketch.ChangedSince]
+// belong to the previous period and are reset as well, so every item counts as changed in the new period's first ChangedSince.
+func (me *Sketch) ResetBucketsKeepHeap(reseed bool) {
+	clear(me.Buckets)
+	me.Total, me.CountDecreases = 0, 0
+	me.snapshot = nil
+	if reseed {
+		me.seedBucketsFromHeap()
+	}
+}
+
+// seedBucketsFromHeap writes each heap item's count into its buckets (in every row) that hold a smaller count.
+func (me *Sketch) seedBucketsFromHeap() {
+	for i := range me.Heap.Items {
+		hb := &me.Heap.Items[i]
+		if hb.Count == 0 {
+			continue
+		}
+		for row := range me.Depth {
+			b := &me.Buckets[me.bucketIndex(hb.Item, row)]
+			if b.Count < hb.Count {
+				b.Fingerprint = hb.Fingerprint
+				b.Count = hb.Count
+			}
+		}
+	}
+}
+
+// Saturation returns the fraction of buckets that are in use (hold a non-zero count), between 0 and 1.
+// A saturated sketch (close to 1) has most of its buckets contended by several items, which degrades accuracy;
+// growing the width via [Sketch.Resize] restores it.
+func (me *Sketch) Saturation() float64 {
+	if len(me.Buckets) == 0 {
+		return 0
+	}
+	used := 0
+	for _, b := range me.Buckets {
+		if b.Count != 0 {
+			used++
+		}
+	}
+	return float64(used) / float64(len(me.Buckets))
+}
+
+// Resize changes the sketch's width (discarding per-row widths set by [WithRowWidths]).
+// The existing bucket counts can't be redistributed, since the buckets don't record which items they counted;
+// instead, the top-K heap is kept and replayed into the new buckets, as in [Sketch.ResetBucketsKeepHeap] with re-seeding.
+// Counts of items outside the top K are lost.
+func (me *Sketch) Resize(width int) {
+	me.Width = width
+	me.RowWidths, me.RowOffsets = nil, nil
+	me.initBuckets()
+	me.seedBucketsFromHeap()
 }
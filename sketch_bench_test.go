@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
 	segmentiotopk "github.com/segmentio/topk"
 )
 
@@ -67,6 +68,24 @@ func BenchmarkSketchIncr(b *testing.B) {
 	}
 }
 
+// BenchmarkSketchIncrVsAdd compares Incr with the equivalent Add of 1.
+func BenchmarkSketchIncrVsAdd(b *testing.B) {
+	for _, method := range []string{"Incr", "Add"} {
+		b.Run(method, func(b *testing.B) {
+			sketch := topk.New(100, topk.WithDepth(4), topk.WithWidth(1024))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				item := items[i%len(items)]
+				if method == "Incr" {
+					sketch.Incr(item)
+				} else {
+					sketch.Add(item, 1)
+				}
+			}
+		})
+	}
+}
+
 // BenchmarkSketchCount benchmarks the Count method of Sketch.
 func BenchmarkSketchCount(b *testing.B) {
 	for _, k := range ks {
@@ -115,6 +134,30 @@ func BenchmarkSketchQuery(b *testing.B) {
 	}
 }
 
+// BenchmarkSketchQueryNegative benchmarks the Query method of Sketch on a query stream of items that are mostly not in the top K.
+func BenchmarkSketchQueryNegative(b *testing.B) {
+	for _, k := range ks {
+		for _, filter := range []bool{false, true} {
+			b.Run(fmt.Sprintf("K=%d_QueryFilter=%v", k, filter), func(b *testing.B) {
+				opts := []topk.Option{topk.WithDepth(3), topk.WithWidth(1024)}
+				if filter {
+					opts = append(opts, topk.WithQueryFilter())
+				}
+				sketch := topk.New(k, opts...)
+				for _, item := range items[:10_000] {
+					sketch.Add(item, uint32(rand.IntN(10)))
+				}
+				queries := items[10_000:20_000]
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					sketch.Query(queries[i%len(queries)])
+				}
+			})
+		}
+	}
+}
+
 // BenchmarkSegmentioTopkSample benchmarks the Sample method of a [github.com/segmentio/topk.HeavyKeeper].
 func BenchmarkSegmentioTopkSample(b *testing.B) {
 	for _, k := range []int{10} {
@@ -150,3 +193,78 @@ func BenchmarkSketchAddForComparison(b *testing.B) {
 		}
 	}
 }
+
+// BenchmarkSketchSortedSlice benchmarks the SortedSlice method of Sketch with a full top-K heap.
+func BenchmarkSketchSortedSlice(b *testing.B) {
+	for _, k := range []int{100, 10_000, 100_000} {
+		b.Run(fmt.Sprintf("K=%d", k), func(b *testing.B) {
+			sketch := topk.New(k, topk.WithDepth(3), topk.WithWidth(8*k))
+			for i := range 4 * k {
+				sketch.Add(items[i], uint32(1+rand.IntN(1000)))
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_ = sketch.SortedSlice()
+			}
+		})
+	}
+}
+
+// BenchmarkSketchTopK benchmarks TopK and TopKInto (with a re-used buffer) with a full top-K heap.
+func BenchmarkSketchTopK(b *testing.B) {
+	for _, k := range []int{100, 10_000} {
+		sketch := topk.New(k, topk.WithDepth(3), topk.WithWidth(8*k))
+		for i := range 4 * k {
+			sketch.Add(items[i], uint32(1+rand.IntN(1000)))
+		}
+		b.Run(fmt.Sprintf("TopK/K=%d", k), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = sketch.TopK()
+			}
+		})
+		b.Run(fmt.Sprintf("TopKInto/K=%d", k), func(b *testing.B) {
+			var buf []heap.Item
+			for i := 0; i < b.N; i++ {
+				buf = sketch.TopKInto(buf)
+			}
+		})
+	}
+}
+
+// BenchmarkNew benchmarks creating a sketch with an eagerly and a lazily computed decay LUT.
+func BenchmarkNew(b *testing.B) {
+	for _, lazy := range []bool{false, true} {
+		b.Run(fmt.Sprintf("LazyDecayLUT=%v", lazy), func(b *testing.B) {
+			opts := []topk.Option{topk.WithDepth(3), topk.WithWidth(256)}
+			if lazy {
+				opts = append(opts, topk.WithLazyDecayLUT())
+			}
+			for i := 0; i < b.N; i++ {
+				_ = topk.New(10, opts...)
+			}
+		})
+	}
+}
+
+// BenchmarkSketchAddBytes compares counting keys held in byte slices (e.g. network buffers)
+// via AddBytes with converting them to strings for Add.
+func BenchmarkSketchAddBytes(b *testing.B) {
+	keys := make([][]byte, len(items))
+	for i, item := range items {
+		keys[i] = []byte(item)
+	}
+	b.Run("Add", func(b *testing.B) {
+		sketch := topk.New(100, topk.WithWidth(8192), topk.WithDepth(3))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sketch.Add(string(keys[i%len(keys)]), 1)
+		}
+	})
+	b.Run("AddBytes", func(b *testing.B) {
+		sketch := topk.New(100, topk.WithWidth(8192), topk.WithDepth(3))
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sketch.AddBytes(keys[i%len(keys)], 1)
+		}
+	})
+}
@@ -1,10 +1,14 @@
 package topk_test
 
 import (
+	"errors"
 	"fmt"
 	"math"
+	"math/rand/v2"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/keilerkonzept/topk"
 	"github.com/keilerkonzept/topk/heap"
 	"github.com/keilerkonzept/topk/internal/sizeof"
@@ -211,15 +215,22 @@ func TestSketchCollisions(t *testing.T) {
 }
 
 func TestSketchErrorBounds(t *testing.T) {
+	t.Run("uniform width", func(t *testing.T) {
+		testSketchErrorBounds(t, 32, topk.WithWidth(32), topk.WithDepth(1))
+	})
+	t.Run("coprime row widths", func(t *testing.T) {
+		testSketchErrorBounds(t, 31+32+33, topk.WithRowWidths([]int{31, 32, 33}))
+	})
+}
+
+func testSketchErrorBounds(t *testing.T, numBuckets int, opts ...topk.Option) {
 	K := 10
 	decay := 0.9
-	width := 32
-	depth := 1
 	noiseItems := 1_000
 	noiseItemsFrequency := 50
 	approxErrorProbability := 1.0
 
-	sketch := topk.New(K, topk.WithWidth(width), topk.WithDepth(depth), topk.WithDecay(float32(decay)))
+	sketch := topk.New(K, append(opts, topk.WithDecay(float32(decay)))...)
 
 	testCases := []struct {
 		item  string
@@ -265,7 +276,7 @@ func TestSketchErrorBounds(t *testing.T) {
 	for _, tc := range testCases {
 		actualCount := sketch.Count(tc.item)
 
-		epsilon := 1 / (approxErrorProbability * (float64(width*depth) * float64(tc.count) * float64(1-decay)))
+		epsilon := 1 / (approxErrorProbability * (float64(numBuckets) * float64(tc.count) * float64(1-decay)))
 
 		lowerBound := float64(tc.count) - math.Ceil(epsilon*float64(totalItems-int(tc.count)))
 		if lowerBound < 0 {
@@ -354,3 +365,1224 @@ func TestSketchVsSegmentio(t *testing.T) {
 		}
 	}
 }
+
+func TestSketch_ResetBucketsKeepHeap(t *testing.T) {
+	for _, reseed := range []bool{false, true} {
+		t.Run(fmt.Sprintf("reseed=%v", reseed), func(t *testing.T) {
+			sketch := topk.New(3, topk.WithCountDecreaseDetection())
+			sketch.Add("a", 30)
+			sketch.Add("b", 20)
+			sketch.Add("c", 10)
+			expected := sketch.Snapshot()
+			sketch.CountDecreases = 1
+
+			sketch.ResetBucketsKeepHeap(reseed)
+
+			if sketch.TotalCount() != 0 || sketch.CountDecreases != 0 {
+				t.Errorf("Expected the total count and count decreases of the new period to be 0, got %d and %d", sketch.TotalCount(), sketch.CountDecreases)
+			}
+			if changed := sketch.ChangedSince(); len(changed) != len(expected) {
+				t.Errorf("Expected all %d items to count as changed in the new period, got %v", len(expected), changed)
+			}
+
+			if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+				t.Error(diff)
+			}
+			for _, item := range expected {
+				if !sketch.Query(item.Item) {
+					t.Errorf("Expected item %q to be in the top-K after reset", item.Item)
+				}
+				if actual := sketch.Count(item.Item); actual != item.Count {
+					t.Errorf("Expected Count(%s) = %d after reset, got %d", item.Item, item.Count, actual)
+				}
+			}
+
+			sketch.Add("a", 1)
+			if sketch.TotalCount() != 1 {
+				t.Errorf("Expected TotalCount() = 1 in the new period, got %d", sketch.TotalCount())
+			}
+			expectedCount := uint32(30)
+			if reseed {
+				expectedCount = 31
+			}
+			if actual := sketch.Count("a"); actual != expectedCount {
+				t.Errorf("Expected Count(a) = %d after adding to the new period, got %d", expectedCount, actual)
+			}
+		})
+	}
+}
+
+func TestEstimateSizeBytes(t *testing.T) {
+	for _, k := range []int{1, 10, 1000} {
+		for _, width := range []int{1, 256, 4096} {
+			for _, depth := range []int{1, 3, 5} {
+				for _, lutSize := range []int{0, 16, 1024} {
+					opts := []topk.Option{topk.WithWidth(width), topk.WithDepth(depth)}
+					if lutSize != 0 {
+						opts = append(opts, topk.WithDecayLUTSize(lutSize))
+					}
+					expected := topk.New(k, opts...).SizeBytes()
+					actual := topk.EstimateSizeBytes(k, width, depth, lutSize)
+					if actual != expected {
+						t.Errorf("EstimateSizeBytes(%d, %d, %d, %d) = %d, expected %d", k, width, depth, lutSize, actual, expected)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSketch_QueryFilter(t *testing.T) {
+	k := 10
+	sketch := topk.New(k, topk.WithQueryFilter(), topk.WithWidth(64), topk.WithDepth(2))
+	plain := topk.New(k, topk.WithWidth(64), topk.WithDepth(2))
+
+	for round := range 3 {
+		for i := range 2000 {
+			item := fmt.Sprintf("item%d", (i*7919+round)%500)
+			sketch.Add(item, uint32(1+i%5))
+		}
+		for i := range 1000 {
+			item := fmt.Sprintf("item%d", i)
+			if sketch.Query(item) != sketch.Heap.Contains(item) {
+				t.Fatalf("round %d: Query(%s) = %v, but heap membership is %v", round, item, sketch.Query(item), sketch.Heap.Contains(item))
+			}
+		}
+		if sketch.SizeBytes() <= plain.SizeBytes() {
+			t.Errorf("Expected the query filter to be included in SizeBytes")
+		}
+	}
+
+	sketch.Reset()
+	for i := range 1000 {
+		if item := fmt.Sprintf("item%d", i); sketch.Query(item) {
+			t.Errorf("Expected Query(%s) = false after reset", item)
+		}
+	}
+}
+
+func TestSketch_CountMoments(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(4096))
+	if mean, stddev := sketch.CountMoments(); mean != 0 || stddev != 0 {
+		t.Errorf("Expected zero moments for an empty sketch, got mean=%v stddev=%v", mean, stddev)
+	}
+
+	for i, count := range []uint32{2, 4, 4, 4, 5} {
+		sketch.Add(fmt.Sprintf("item%d", i), count)
+	}
+	sketch.Add("item5", 5)
+	sketch.Add("item6", 7)
+	sketch.Add("item7", 9)
+
+	// top 5: 4, 5, 5, 7, 9
+	mean, stddev := sketch.CountMoments()
+	if mean != 6 {
+		t.Errorf("Expected mean = 6, got %v", mean)
+	}
+	if expected := math.Sqrt(3.2); math.Abs(stddev-expected) > 1e-9 {
+		t.Errorf("Expected stddev = %v, got %v", expected, stddev)
+	}
+}
+
+func TestSketch_AddPrecomputed(t *testing.T) {
+	// with decay 0 or 1, collisions are resolved deterministically
+	for _, decay := range []float32{0, 1} {
+		t.Run(fmt.Sprintf("Decay=%v", decay), func(t *testing.T) {
+			opts := []topk.Option{topk.WithWidth(16), topk.WithDepth(3), topk.WithDecay(decay)}
+			expected := topk.New(5, opts...)
+			actual := topk.New(5, opts...)
+
+			for i := range 1000 {
+				item := fmt.Sprintf("item%d", i%37)
+				increment := uint32(1 + i%7)
+				bucketIndices := make([]int, actual.Depth)
+				for row := range bucketIndices {
+					bucketIndices[row] = topk.BucketIndex(item, row, actual.Width)
+				}
+				expectedTop := expected.Add(item, increment)
+				actualTop := actual.AddPrecomputed(item, increment, topk.Fingerprint(item), bucketIndices)
+				if expectedTop != actualTop {
+					t.Fatalf("AddPrecomputed(%s) = %v, Add(%s) = %v", item, actualTop, item, expectedTop)
+				}
+			}
+
+			if diff := cmp.Diff(expected.Buckets, actual.Buckets); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(expected.SortedSlice(), actual.SortedSlice()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestSketch_AddPrecomputed_InvalidIndices(t *testing.T) {
+	sketch := topk.New(5, topk.WithDepth(3))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected AddPrecomputed to panic for a wrong number of bucket indices")
+		}
+	}()
+	sketch.AddPrecomputed("item", 1, topk.Fingerprint("item"), []int{0, 1})
+}
+
+func TestSketch_AddExact(t *testing.T) {
+	for range 20 {
+		sketch := topk.New(3, topk.WithWidth(8), topk.WithDepth(2))
+		for i := range 100 {
+			sketch.Add(fmt.Sprintf("noise%d", i), 100)
+		}
+
+		if !sketch.AddExact("purchase", 1000) {
+			t.Fatal("Expected a large AddExact to enter the top-K")
+		}
+		count := sketch.Count("purchase")
+		if count < 1000-100*2 || count > 1000 {
+			t.Errorf("Expected Count(purchase) in [800, 1000], got %d", count)
+		}
+		for i := range 20 {
+			sketch.Add(fmt.Sprintf("noise%d", i), 10)
+		}
+		if !sketch.Query("purchase") {
+			t.Errorf("Expected purchase to remain in the top-K, got %v", sketch.SortedSlice())
+		}
+	}
+}
+
+func TestSketch_Drain(t *testing.T) {
+	sketch := topk.New(3)
+	for i, item := range []string{"item1", "item2", "item3", "item4"} {
+		sketch.Add(item, uint32(i+1))
+	}
+	expected := sketch.SortedSlice()
+
+	if diff := cmp.Diff(expected, sketch.Drain()); diff != "" {
+		t.Error(diff)
+	}
+	if len(sketch.SortedSlice()) != 0 {
+		t.Errorf("Expected no items in top-K after drain")
+	}
+	if sketch.Count("item4") != 0 {
+		t.Errorf("Expected count = 0 after drain, got %d", sketch.Count("item4"))
+	}
+}
+
+func TestSketch_WithRowWidths(t *testing.T) {
+	widths := []int{7, 8, 9}
+	sketch := topk.New(3, topk.WithRowWidths(widths), topk.WithDepth(5), topk.WithDecay(1))
+
+	if sketch.Depth != len(widths) {
+		t.Errorf("Expected Depth = %d, got %d", len(widths), sketch.Depth)
+	}
+	if len(sketch.Buckets) != 7+8+9 {
+		t.Errorf("Expected %d buckets, got %d", 7+8+9, len(sketch.Buckets))
+	}
+	if diff := cmp.Diff([]int{0, 7, 15}, sketch.RowOffsets); diff != "" {
+		t.Errorf("RowOffsets mismatch (-want +got):\n%s", diff)
+	}
+
+	sketch.Add("a", 5)
+	for row, w := range widths {
+		k := topk.RowBucketIndex("a", row, sketch.RowWidths, sketch.RowOffsets)
+		if k < sketch.RowOffsets[row] || k >= sketch.RowOffsets[row]+w {
+			t.Errorf("Bucket index %d of row %d is outside the row", k, row)
+		}
+		if sketch.Buckets[k].Count != 5 {
+			t.Errorf("Expected count 5 in row %d, got %d", row, sketch.Buckets[k].Count)
+		}
+	}
+	if c := sketch.Freeze().Count("a"); c != 5 {
+		t.Errorf("Expected frozen count 5, got %d", c)
+	}
+	if err := sketch.Merge(topk.New(3, topk.WithWidth(9), topk.WithDepth(3))); err == nil {
+		t.Error("Expected an error merging sketches with different row widths")
+	}
+}
+
+func TestSketch_CutoffCount(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDecay(1))
+
+	sketch.Add("a", 10)
+	sketch.Add("b", 20)
+	if c := sketch.CutoffCount(); c != 0 {
+		t.Errorf("Expected cutoff 0 while the top K is not full, got %d", c)
+	}
+
+	sketch.Add("c", 5)
+	sketch.Add("d", 30)
+	items := sketch.SortedSlice()
+	if expected, actual := items[len(items)-1].Count, sketch.CutoffCount(); actual != expected {
+		t.Errorf("Expected cutoff %d (the smallest top-K count), got %d", expected, actual)
+	}
+	if c := sketch.CutoffCount(); c != 10 {
+		t.Errorf("Expected cutoff 10, got %d", c)
+	}
+}
+
+func TestSketch_WithMaxIncrementPerAdd(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithMaxIncrementPerAdd(100))
+
+	sketch.Add("burst", 1_000_000)
+	if c := sketch.Count("burst"); c != 100 {
+		t.Errorf("Expected Count(burst) = 100, got %d", c)
+	}
+	sketch.Add("burst", 5)
+	if c := sketch.Count("burst"); c != 105 {
+		t.Errorf("Expected increments below the cap to be counted fully, got %d", c)
+	}
+}
+
+func TestSketch_WithLazyDecayLUT(t *testing.T) {
+	eager := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(0.5))
+	lazy := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(0.5), topk.WithLazyDecayLUT())
+
+	for i, d := range lazy.DecayLUT {
+		if d != 0 {
+			t.Fatalf("Expected DecayLUT[%d] to be uncomputed, got %v", i, d)
+		}
+	}
+
+	lazy.Add("a", 3)
+	lazy.Add("b", 100)
+	for i, d := range lazy.DecayLUT {
+		if d != 0 && d != eager.DecayLUT[i] {
+			t.Errorf("Expected DecayLUT[%d] = %v, got %v", i, eager.DecayLUT[i], d)
+		}
+	}
+	if lazy.DecayLUT[3] != eager.DecayLUT[3] {
+		t.Errorf("Expected the colliding count's entry DecayLUT[3] = %v to be computed, got %v", eager.DecayLUT[3], lazy.DecayLUT[3])
+	}
+
+	// With a decay of 1, every collision decrements the counter.
+	certain := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1), topk.WithLazyDecayLUT())
+	certain.Add("a", 5)
+	certain.Add("b", 3)
+	if diff := cmp.Diff(topk.Bucket{Fingerprint: topk.Fingerprint("a"), Count: 2}, certain.Buckets[0]); diff != "" {
+		t.Errorf("Bucket mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_CountConfidence(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	if count, rows := sketch.CountConfidence("a"); count != 10 || rows != 3 {
+		t.Errorf("Expected (10, 3), got (%d, %d)", count, rows)
+	}
+	if count, rows := sketch.CountConfidence("missing"); count != 0 || rows != 0 {
+		t.Errorf("Expected (0, 0), got (%d, %d)", count, rows)
+	}
+
+	// Claim one of b's buckets with another item's fingerprint.
+	sketch.Add("b", 10)
+	k := topk.BucketIndex("b", 1, sketch.Width)
+	sketch.Buckets[k] = topk.Bucket{Fingerprint: topk.Fingerprint("c"), Count: 100}
+	if count, rows := sketch.CountConfidence("b"); count != 10 || rows != 2 {
+		t.Errorf("Expected (10, 2) for a collided item, got (%d, %d)", count, rows)
+	}
+}
+
+func TestSketch_ResetStats(t *testing.T) {
+	opts := []topk.Option{topk.WithWidth(64), topk.WithDepth(3), topk.WithQueryFilter(), topk.WithCountDecreaseDetection()}
+	sketch := topk.New(5, opts...)
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", i%100), uint32(1+i%7))
+	}
+
+	sketch.Reset()
+
+	if diff := cmp.Diff(make([]topk.Bucket, len(sketch.Buckets)), sketch.Buckets); diff != "" {
+		t.Errorf("Expected zero buckets after Reset (-want +got):\n%s", diff)
+	}
+	if n := sketch.Heap.StoredKeysBytes; n != 0 {
+		t.Errorf("Expected StoredKeysBytes = 0 after Reset, got %d", n)
+	}
+	if n := sketch.TotalCount(); n != 0 {
+		t.Errorf("Expected TotalCount = 0 after Reset, got %d", n)
+	}
+	if n := sketch.CountDecreases; n != 0 {
+		t.Errorf("Expected CountDecreases = 0 after Reset, got %d", n)
+	}
+	if c := sketch.CutoffCount(); c != 0 {
+		t.Errorf("Expected CutoffCount = 0 after Reset, got %d", c)
+	}
+	if mean, stddev := sketch.CountMoments(); mean != 0 || stddev != 0 {
+		t.Errorf("Expected zero count moments after Reset, got (%v, %v)", mean, stddev)
+	}
+	for i := range 100 {
+		if item := fmt.Sprintf("item%d", i); sketch.Query(item) {
+			t.Errorf("Expected %s not to be in the top K after Reset", item)
+		}
+	}
+	if expected, actual := topk.New(5, opts...).SizeBytes(), sketch.SizeBytes(); actual != expected {
+		t.Errorf("Expected SizeBytes = %d (as for a new sketch) after Reset, got %d", expected, actual)
+	}
+}
+
+func TestSketch_ItemsByFingerprint(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	forward := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	backward := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	for i, item := range items {
+		forward.Add(item, uint32(i+1))
+		backward.Add(items[len(items)-1-i], uint32(len(items)-i))
+	}
+
+	actual := forward.ItemsByFingerprint()
+	if diff := cmp.Diff(actual, backward.ItemsByFingerprint()); diff != "" {
+		t.Errorf("Expected the same order regardless of insertion order (-forward +backward):\n%s", diff)
+	}
+	if len(actual) != len(items) {
+		t.Fatalf("Expected %d items, got %d", len(items), len(actual))
+	}
+	for i := 1; i < len(actual); i++ {
+		if actual[i-1].Fingerprint > actual[i].Fingerprint {
+			t.Errorf("Expected items ordered by fingerprint, got %v", actual)
+		}
+	}
+}
+
+func TestSketch_WithSampling(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	sampled := topk.New(10, topk.WithWidth(4096), topk.WithSampling(0.1), topk.WithRand(r))
+	full := topk.New(10, topk.WithWidth(4096))
+
+	for i := range 200_000 {
+		item := fmt.Sprintf("item%d", i%(1+i%10))
+		sampled.Incr(item)
+		full.Incr(item)
+	}
+
+	for _, item := range full.SortedSlice() {
+		expected, actual := float64(item.Count), float64(sampled.Count(item.Item))
+		if math.Abs(actual-expected) > 0.05*expected {
+			t.Errorf("Expected sampled Count(%s) within 5%% of %v, got %v", item.Item, expected, actual)
+		}
+	}
+}
+
+func TestSketch_WithSampling_AddPrecomputed(t *testing.T) {
+	newSketch := func() *topk.Sketch {
+		return topk.New(10, topk.WithWidth(64), topk.WithDepth(3), topk.WithSampling(0.1), topk.WithRand(rand.New(rand.NewPCG(1, 2))))
+	}
+	added, precomputed := newSketch(), newSketch()
+	bucketIndices := make([]int, 3)
+	for i := range 10_000 {
+		item := fmt.Sprintf("item%d", i%(1+i%10))
+		added.Add(item, 2)
+		for row := range bucketIndices {
+			bucketIndices[row] = topk.BucketIndex(item, row, 64)
+		}
+		precomputed.AddPrecomputed(item, 2, topk.Fingerprint(item), bucketIndices)
+	}
+	// Both entry points sample and scale the same way, so with equal random sources they count the same.
+	if !added.Equal(precomputed) {
+		t.Errorf("Expected AddPrecomputed to count like Add with sampling, got totals %d and %d", added.TotalCount(), precomputed.TotalCount())
+	}
+}
+
+func TestSketch_CountAcrossRows(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(16), topk.WithDepth(3))
+	for i := range 10_000 {
+		sketch.Add(fmt.Sprintf("item%d", i%100), uint32(1+i%5))
+	}
+
+	for i := range 100 {
+		item := fmt.Sprintf("item%d", i)
+		minCount, maxCount, sum := sketch.CountMinAcrossRows(item), sketch.Count(item), sketch.CountSumAcrossRows(item)
+		if minCount > sum {
+			t.Errorf("%s: expected min %d <= sum %d", item, minCount, sum)
+		}
+		if sketch.Query(item) {
+			continue
+		}
+		if minCount > maxCount || maxCount > sum {
+			t.Errorf("%s: expected min %d <= max %d <= sum %d", item, minCount, maxCount, sum)
+		}
+	}
+
+	exact := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3))
+	exact.Add("a", 7)
+	if minCount, sum := exact.CountMinAcrossRows("a"), exact.CountSumAcrossRows("a"); minCount != 7 || sum != 21 {
+		t.Errorf("Expected min 7 and sum 21 for an item without collisions, got %d and %d", minCount, sum)
+	}
+}
+
+func TestSketch_WithIgnoreEmptyKeys(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithIgnoreEmptyKeys())
+	for i := range 100 {
+		if sketch.Incr("") || sketch.Add("", 10) {
+			t.Fatal("Expected adding the empty string to return false")
+		}
+		sketch.Add(fmt.Sprintf("item%d", i%2), 1)
+	}
+
+	for _, item := range sketch.SortedSlice() {
+		if item.Item == "" {
+			t.Errorf("Expected no empty key in the top K, got %v", sketch.SortedSlice())
+		}
+	}
+	if c := sketch.Count(""); c != 0 {
+		t.Errorf("Expected Count(\"\") = 0, got %d", c)
+	}
+
+	// An empty key counted before the option was set is not decremented either.
+	sketch.IgnoreEmptyKeys = false
+	sketch.Add("", 10)
+	sketch.IgnoreEmptyKeys = true
+	sketch.Decr("", 4)
+	sketch.IgnoreEmptyKeys = false
+	if c := sketch.Count(""); c != 10 {
+		t.Errorf("Expected Decr(\"\") to be a no-op, got Count(\"\") = %d", c)
+	}
+}
+
+func TestSketch_WithOrderedIter(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(1024), topk.WithOrderedIter())
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", i%30), uint32(1+i%13))
+	}
+
+	var iterated []heap.Item
+	for item := range sketch.Iter {
+		iterated = append(iterated, *item)
+	}
+	if diff := cmp.Diff(sketch.SortedSlice(), iterated); diff != "" {
+		t.Errorf("Iter order mismatch (-SortedSlice +Iter):\n%s", diff)
+	}
+}
+
+func TestSketch_SortedSliceActive(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	for item, count := range map[string]uint32{"a": 50, "b": 20, "c": 10, "d": 2, "e": 1} {
+		sketch.Add(item, count)
+	}
+
+	var actual []string
+	for _, item := range sketch.SortedSliceActive(10) {
+		actual = append(actual, item.Item)
+	}
+	if diff := cmp.Diff([]string{"a", "b", "c"}, actual); diff != "" {
+		t.Errorf("Active items mismatch (-want +got):\n%s", diff)
+	}
+	if n := len(sketch.SortedSliceActive(0)); n != 5 {
+		t.Errorf("Expected all 5 items for minCount 0, got %d", n)
+	}
+	if n := len(sketch.SortedSliceActive(100)); n != 0 {
+		t.Errorf("Expected no items for minCount 100, got %d", n)
+	}
+}
+
+func TestSketch_TopKInto(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	for item, count := range map[string]uint32{"a": 50, "b": 20, "c": 20, "d": 2} {
+		sketch.Add(item, count)
+	}
+
+	expected := sketch.TopK()
+	if diff := cmp.Diff(sketch.SortedSlice(), expected); diff != "" {
+		t.Errorf("TopK mismatch (-SortedSlice +TopK):\n%s", diff)
+	}
+	if diff := cmp.Diff(expected, sketch.TopKInto(nil)); diff != "" {
+		t.Errorf("TopKInto(nil) mismatch (-want +got):\n%s", diff)
+	}
+
+	buf := make([]heap.Item, 8, 16)
+	buf[0] = heap.Item{Item: "stale", Count: 1000}
+	actual := sketch.TopKInto(buf)
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("TopKInto(buf) mismatch (-want +got):\n%s", diff)
+	}
+	if &actual[0] != &buf[0] {
+		t.Error("Expected TopKInto to re-use the buffer")
+	}
+}
+
+func TestSketch_Rank(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDecay(0))
+	for item, count := range map[string]uint32{"a": 50, "b": 20, "c": 20, "d": 20, "e": 2} {
+		sketch.Add(item, count)
+	}
+
+	for i, item := range sketch.SortedSlice() {
+		if rank, ok := sketch.Rank(item.Item); !ok || rank != i+1 {
+			t.Errorf("Expected Rank(%s) = %d, got %d, %v", item.Item, i+1, rank, ok)
+		}
+	}
+	if rank, ok := sketch.Rank("c"); !ok || rank != 3 {
+		t.Errorf("Expected the tied item c at rank 3, got %d, %v", rank, ok)
+	}
+	if rank, ok := sketch.Rank("x"); ok || rank != 0 {
+		t.Errorf("Expected no rank for an item outside the top K, got %d, %v", rank, ok)
+	}
+}
+
+func TestSketch_ItemsNear(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(1024), topk.WithDecay(0))
+	for item, count := range map[string]uint32{"a": 50, "b": 21, "c": 20, "d": 18, "e": 15, "f": 2} {
+		sketch.Add(item, count)
+	}
+
+	for _, tc := range []struct {
+		count, tolerance uint32
+		expected         []string
+	}{
+		{20, 2, []string{"b", "c", "d"}},
+		{20, 0, []string{"c"}},
+		{1, 5, []string{"f"}},
+		{math.MaxUint32, 10, nil},
+		{35, 13, nil},
+	} {
+		var actual []string
+		for _, item := range sketch.ItemsNear(tc.count, tc.tolerance) {
+			actual = append(actual, item.Item)
+		}
+		if diff := cmp.Diff(tc.expected, actual); diff != "" {
+			t.Errorf("ItemsNear(%d, %d) mismatch (-want +got):\n%s", tc.count, tc.tolerance, diff)
+		}
+	}
+}
+
+func TestSketch_ItemsAbove(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(1024), topk.WithDecay(0))
+	if items := sketch.ItemsAbove(0); items != nil {
+		t.Errorf("Expected no items in an empty sketch, got %v", items)
+	}
+	for item, count := range map[string]uint32{"a": 50, "b": 21, "c": 20, "d": 18, "e": 2} {
+		sketch.Add(item, count)
+	}
+
+	for threshold, expected := range map[uint32][]string{
+		0:  {"a", "b", "c", "d", "e"},
+		20: {"a", "b", "c"},
+		21: {"a", "b"},
+		51: nil,
+	} {
+		var actual []string
+		for _, item := range sketch.ItemsAbove(threshold) {
+			actual = append(actual, item.Item)
+		}
+		if diff := cmp.Diff(expected, actual); diff != "" {
+			t.Errorf("ItemsAbove(%d) mismatch (-want +got):\n%s", threshold, diff)
+		}
+	}
+
+	// The result is a copy of the heap items.
+	items := sketch.ItemsAbove(20)
+	items[0].Count = 0
+	if c := sketch.Count("a"); c != 50 {
+		t.Errorf("Expected Count(a) = 50 after modifying the result, got %d", c)
+	}
+}
+
+func TestAddAll(t *testing.T) {
+	sketches := []*topk.Sketch{
+		topk.New(5, topk.WithWidth(1024), topk.WithDecay(0)),
+		topk.New(5, topk.WithWidth(1024), topk.WithDecay(0)),
+		topk.New(3, topk.WithWidth(2048), topk.WithDecay(0)),
+	}
+	for i := range 100 {
+		topk.AddAll(fmt.Sprintf("item%d", i%10), uint32(1+i%10), sketches...)
+	}
+
+	if diff := cmp.Diff(sketches[0], sketches[1], cmp.AllowUnexported(topk.Sketch{})); diff != "" {
+		t.Errorf("Expected identical sketches (-first +second):\n%s", diff)
+	}
+	if diff := cmp.Diff(sketches[0].SortedSlice()[:3], sketches[2].SortedSlice()); diff != "" {
+		t.Errorf("Top 3 mismatch (-first +third):\n%s", diff)
+	}
+}
+
+func TestSketch_KForMass(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(4096), topk.WithDecay(0))
+	// Two items carry 80% of the mass, spread as 500 + 300 out of 1000.
+	sketch.Add("a", 500)
+	sketch.Add("b", 300)
+	for i := range 200 {
+		sketch.Add(fmt.Sprintf("tail%d", i), 1)
+	}
+
+	if n := sketch.TotalCount(); n != 1000 {
+		t.Fatalf("Expected TotalCount = 1000, got %d", n)
+	}
+	for fraction, expected := range map[float64]int{0: 0, 0.5: 1, 0.8: 2, 0.801: 3, 1: 10} {
+		if actual := sketch.KForMass(fraction); actual != expected {
+			t.Errorf("Expected KForMass(%v) = %d, got %d", fraction, expected, actual)
+		}
+	}
+}
+
+func TestSketch_Decr(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Add("b", 20)
+	sketch.Add("c", 30)
+
+	sketch.Decr("c", 25)
+	if c := sketch.Count("c"); c != 5 {
+		t.Errorf("Expected Count(c) = 5, got %d", c)
+	}
+	if items := sketch.SortedSlice(); items[len(items)-1].Item != "c" {
+		t.Errorf("Expected c to rank last after the decrement, got %v", items)
+	}
+
+	// Decrementing below zero clamps the counters and evicts the item from the heap.
+	sketch.Decr("a", 100)
+	if c := sketch.Count("a"); c != 0 {
+		t.Errorf("Expected Count(a) = 0, got %d", c)
+	}
+	if sketch.Query("a") {
+		t.Error("Expected a to be evicted from the heap")
+	}
+	if n := sketch.TotalCount(); n != 60-25-10 {
+		t.Errorf("Expected TotalCount = %d, got %d", 60-25-10, n)
+	}
+
+	// Decrementing an item without matching buckets is a no-op.
+	sketch.Decr("missing", 5)
+	if n := sketch.TotalCount(); n != 60-25-10 {
+		t.Errorf("Expected TotalCount = %d after a no-op decrement, got %d", 60-25-10, n)
+	}
+}
+
+func TestSketch_WithDecayEventTracking(t *testing.T) {
+	// A single bucket and a decay of 1 force every colliding increment to decrement the counter.
+	sketch := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1), topk.WithDecayEventTracking())
+	sketch.Add("a", 10)
+	sketch.Add("b", 3)
+	sketch.Add("c", 2)
+
+	if n := sketch.Heap.Get("a").DecayEvents; n != 5 {
+		t.Errorf("Expected 5 decay events for a, got %d", n)
+	}
+	if n := sketch.Heap.Get("b").DecayEvents; n != 0 {
+		t.Errorf("Expected no decay events for b, got %d", n)
+	}
+
+	untracked := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1))
+	untracked.Add("a", 10)
+	untracked.Add("b", 3)
+	if n := untracked.Heap.Get("a").DecayEvents; n != 0 {
+		t.Errorf("Expected no decay events without tracking, got %d", n)
+	}
+}
+
+func TestSketch_WithCountDecreaseDetection(t *testing.T) {
+	// A single bucket and a decay of 1 make every colliding increment decrement the counter.
+	sketch := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1), topk.WithCountDecreaseDetection())
+	sketch.Add("a", 5)
+	sketch.Add("a", 1)
+	if n := sketch.CountDecreases; n != 0 {
+		t.Errorf("Expected no decreases without collisions, got %d", n)
+	}
+
+	sketch.Add("b", 3) // decays a's counter to 3
+	sketch.Add("a", 1) // a's heap count drops from 6 to 4
+	if n := sketch.CountDecreases; n != 1 {
+		t.Errorf("Expected 1 decrease, got %d", n)
+	}
+	if c := sketch.Count("a"); c != 4 {
+		t.Errorf("Expected Count(a) = 4, got %d", c)
+	}
+}
+
+// firstByteHasher hashes items by their first byte, to make collisions deterministic.
+func firstByteHasher() (func(string) uint32, func(string, int, int) int) {
+	fingerprint := func(item string) uint32 { return uint32(item[0]) }
+	bucketIndex := func(item string, row, width int) int { return row*width + int(item[0])%width }
+	return fingerprint, bucketIndex
+}
+
+func TestSketch_WithHasher(t *testing.T) {
+	// "a" and "e" collide in every row of width 4, "b" has buckets of its own.
+	// A decay of 0 keeps "e" from claiming the buckets of "a".
+	sketch := topk.New(3, topk.WithWidth(4), topk.WithDepth(2), topk.WithDecay(0), topk.WithHasher(firstByteHasher()))
+	sketch.Add("a", 3)
+	sketch.Add("b", 2)
+	sketch.Add("e", 5)
+
+	expected := []heap.Item{
+		{Fingerprint: 'a', Item: "a", Count: 3},
+		{Fingerprint: 'b', Item: "b", Count: 2},
+	}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+	for _, b := range sketch.Buckets {
+		if b.Count != 0 && b.Fingerprint != 'a' && b.Fingerprint != 'b' {
+			t.Errorf("Unexpected bucket %+v", b)
+		}
+	}
+	if c := sketch.Count("e"); c != 0 {
+		t.Errorf("Expected Count(e) = 0, got %d", c)
+	}
+	if c := sketch.Freeze().Count("a"); c != 3 {
+		t.Errorf("Expected frozen Count(a) = 3, got %d", c)
+	}
+
+	fingerprint, _ := sketch.Hasher()
+	if fp := fingerprint("a"); fp != 'a' {
+		t.Errorf("Expected the custom fingerprint 'a', got %d", fp)
+	}
+	fingerprint, _ = topk.New(3).Hasher()
+	if fp := fingerprint("a"); fp != topk.Fingerprint("a") {
+		t.Errorf("Expected the default fingerprint %d, got %d", topk.Fingerprint("a"), fp)
+	}
+}
+
+func TestSketch_WithSeed(t *testing.T) {
+	opts := []topk.Option{topk.WithWidth(64), topk.WithDepth(3), topk.WithDecay(0)}
+	unseeded := topk.New(5, opts...)
+	seed0 := topk.New(5, append(opts, topk.WithSeed(0))...)
+	seed1 := topk.New(5, append(opts, topk.WithSeed(1))...)
+	seed2 := topk.New(5, append(opts, topk.WithSeed(2))...)
+	for i := range 1000 {
+		item := fmt.Sprintf("item%d", i%50)
+		for _, s := range []*topk.Sketch{unseeded, seed0, seed1, seed2} {
+			s.Add(item, 1)
+		}
+	}
+
+	if diff := cmp.Diff(unseeded.Buckets, seed0.Buckets); diff != "" {
+		t.Errorf("Expected seed 0 to match the unseeded sketch (-unseeded +seed0):\n%s", diff)
+	}
+	if cmp.Equal(seed1.Buckets, seed2.Buckets) {
+		t.Error("Expected different bucket distributions for different seeds")
+	}
+	if err := seed1.Merge(seed2); !errors.Is(err, topk.ErrIncompatibleSketches) {
+		t.Errorf("Expected ErrIncompatibleSketches for different seeds, got %v", err)
+	}
+
+	frozen := seed1.Freeze()
+	for i := range 50 {
+		item := fmt.Sprintf("item%d", i)
+		if c := frozen.Count(item); c != seed1.Count(item) {
+			t.Errorf("Expected frozen Count(%s) = %d, got %d", item, seed1.Count(item), c)
+		}
+	}
+}
+
+func TestSketch_WithLifetimeCounts(t *testing.T) {
+	// A single bucket and a decay of 1 make every colliding increment decrement the counter.
+	sketch := topk.New(3, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1), topk.WithLifetimeCounts())
+	sketch.Add("a", 5)
+	sketch.Add("b", 3) // decays a's counter to 2
+	sketch.Add("a", 1)
+
+	if item := sketch.Heap.Get("a"); item == nil || item.Count != 3 || item.LifetimeCount != 6 {
+		t.Errorf("Expected a with count 3 and lifetime count 6, got %+v", item)
+	}
+
+	// Items leaving the heap lose their lifetime counts.
+	sketch = topk.New(1, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1), topk.WithLifetimeCounts())
+	sketch.Add("a", 2)
+	sketch.Add("b", 5) // takes over the bucket with a count of 3, and a's heap slot
+	sketch.Add("a", 4) // takes over the bucket with a count of 1, too small for the heap
+	sketch.Add("a", 3) // re-enters the heap
+	expected := []heap.Item{{Fingerprint: topk.Fingerprint("a"), Item: "a", Count: 4, LifetimeCount: 3}}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_Incr_EquivalentToAdd(t *testing.T) {
+	// With equally seeded random sources, Incr and Add(item, 1) draw the same decay decisions,
+	// so a narrow sketch with many collisions ends up in the same state.
+	opts := []topk.Option{topk.WithWidth(16), topk.WithDepth(3), topk.WithDecay(0.9), topk.WithDecayEventTracking()}
+	incr := topk.New(5, append(opts, topk.WithRand(rand.New(rand.NewPCG(1, 2))))...)
+	add := topk.New(5, append(opts, topk.WithRand(rand.New(rand.NewPCG(1, 2))))...)
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 10_000 {
+		item := fmt.Sprintf("item%d", int(r.ExpFloat64()*10))
+		if a, b := incr.Incr(item), add.Add(item, 1); a != b {
+			t.Fatalf("Expected Incr(%s) = Add(%s, 1), got %v and %v", item, item, a, b)
+		}
+	}
+
+	if diff := cmp.Diff(add.Buckets, incr.Buckets); diff != "" {
+		t.Errorf("Buckets mismatch (-add +incr):\n%s", diff)
+	}
+	if diff := cmp.Diff(add.SortedSlice(), incr.SortedSlice()); diff != "" {
+		t.Errorf("Top K mismatch (-add +incr):\n%s", diff)
+	}
+	if incr.TotalCount() != add.TotalCount() {
+		t.Errorf("Expected TotalCount = %d, got %d", add.TotalCount(), incr.TotalCount())
+	}
+}
+
+func TestSketch_CountWithError(t *testing.T) {
+	sketch := topk.New(20, topk.WithWidth(64), topk.WithDepth(3), topk.WithDecay(0.9), topk.WithRand(rand.New(rand.NewPCG(1, 2))))
+	r := rand.New(rand.NewPCG(3, 4))
+	truth := map[string]uint32{}
+	for range 50_000 {
+		item := fmt.Sprintf("item%d", int(r.ExpFloat64()*20))
+		truth[item]++
+		sketch.Incr(item)
+	}
+
+	bounded := 0
+	for item, n := range truth {
+		count, maxUnderestimate := sketch.CountWithError(item)
+		if n < count || n > count+maxUnderestimate {
+			t.Errorf("Expected the true count %d of %s in [%d, %d]", n, item, count, count+maxUnderestimate)
+		}
+		if maxUnderestimate < n/2 {
+			bounded++
+		}
+	}
+	// The bound is only informative for the heavy hitters.
+	if bounded < 10 {
+		t.Errorf("Expected informative bounds for at least 10 items, got %d", bounded)
+	}
+
+	exact := topk.New(5, topk.WithWidth(64), topk.WithDecay(0))
+	exact.Add("a", 10)
+	if count, maxUnderestimate := exact.CountWithError("a"); count != 10 || maxUnderestimate != 0 {
+		t.Errorf("Expected (10, 0) without decay, got (%d, %d)", count, maxUnderestimate)
+	}
+	if count, maxUnderestimate := exact.CountWithError("b"); count != 0 || maxUnderestimate != 10 {
+		t.Errorf("Expected (0, 10) for an uncounted item, got (%d, %d)", count, maxUnderestimate)
+	}
+}
+
+func TestSketch_WithTiePolicy(t *testing.T) {
+	for policy, expected := range map[heap.TiePolicy]string{heap.TieReplace: "b", heap.TieReject: "a"} {
+		sketch := topk.New(1, topk.WithWidth(1024), topk.WithDecay(0), topk.WithTiePolicy(policy))
+		sketch.Add("a", 5)
+		sketch.Add("b", 5)
+		if items := sketch.SortedSlice(); len(items) != 1 || items[0].Item != expected {
+			t.Errorf("policy %d: expected the top K to hold only %s, got %v", policy, expected, items)
+		}
+	}
+}
+
+func TestSketch_Clone(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0), topk.WithQueryFilter())
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+
+	clone := sketch.Clone()
+	if diff := cmp.Diff(sketch.SortedSlice(), clone.SortedSlice()); diff != "" {
+		t.Errorf("Clone mismatch (-original +clone):\n%s", diff)
+	}
+
+	sketch.Add("c", 20)
+	clone.Add("b", 30)
+	clone.Add("d", 1)
+
+	expectedOriginal := []heap.Item{
+		{Fingerprint: topk.Fingerprint("c"), Item: "c", Count: 20},
+		{Fingerprint: topk.Fingerprint("a"), Item: "a", Count: 10},
+		{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: 5},
+	}
+	expectedClone := []heap.Item{
+		{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: 35},
+		{Fingerprint: topk.Fingerprint("a"), Item: "a", Count: 10},
+		{Fingerprint: topk.Fingerprint("d"), Item: "d", Count: 1},
+	}
+	if diff := cmp.Diff(expectedOriginal, sketch.SortedSlice()); diff != "" {
+		t.Errorf("Original mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(expectedClone, clone.SortedSlice()); diff != "" {
+		t.Errorf("Clone mismatch (-want +got):\n%s", diff)
+	}
+	if sketch.Query("d") || clone.Query("c") {
+		t.Error("Expected the query filters and heap indices to be independent")
+	}
+	if sketch.TotalCount() != 35 || clone.TotalCount() != 46 {
+		t.Errorf("Expected total counts 35 and 46, got %d and %d", sketch.TotalCount(), clone.TotalCount())
+	}
+}
+
+func TestSketch_Equal(t *testing.T) {
+	newSketch := func(opts ...topk.Option) *topk.Sketch {
+		return topk.New(3, append([]topk.Option{topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0)}, opts...)...)
+	}
+	sketch := newSketch()
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	sketch.Add("c", 20)
+
+	// The same items in another order, giving another heap order, and with another (derived) decay LUT.
+	reordered := newSketch(topk.WithDecayLUTSize(16))
+	reordered.Add("c", 20)
+	reordered.Add("b", 5)
+	reordered.Add("a", 10)
+	if !sketch.Equal(reordered) || !reordered.Equal(sketch) {
+		t.Error("Expected sketches with the same items to be equal regardless of heap order")
+	}
+	if !sketch.Equal(sketch.Clone()) {
+		t.Error("Expected a sketch to equal its clone")
+	}
+
+	narrow := topk.New(3, topk.WithWidth(512), topk.WithDepth(3), topk.WithDecay(0))
+	narrow.Add("a", 10)
+	narrow.Add("b", 5)
+	narrow.Add("c", 20)
+	if sketch.Equal(narrow) {
+		t.Error("Expected sketches of different widths to differ")
+	}
+
+	higher := sketch.Clone()
+	higher.Add("b", 1)
+	if sketch.Equal(higher) || higher.Equal(sketch) {
+		t.Error("Expected sketches with different counts to differ")
+	}
+
+	// The tracking fields of the top-K items are bookkeeping and don't affect equality.
+	tracked := newSketch(topk.WithLastUpdateTracking(), topk.WithDecayEventTracking(), topk.WithLifetimeCounts())
+	tracked.Add("a", 4)
+	tracked.Add("a", 6)
+	tracked.Add("b", 5)
+	tracked.Add("c", 20)
+	if tracked.Heap.Get("a").LifetimeCount == 0 || tracked.Heap.Get("a").LastUpdateUnixNano == 0 {
+		t.Fatalf("Expected tracking data for a, got %+v", *tracked.Heap.Get("a"))
+	}
+	if !sketch.Equal(tracked) || !tracked.Equal(sketch) {
+		t.Error("Expected sketches with the same counts to be equal regardless of tracking data")
+	}
+}
+
+func TestSketch_HeapStats(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDecay(0))
+	type stats struct {
+		Used, Capacity int
+		MinCount       uint32
+	}
+	heapStats := func() stats {
+		used, capacity, minCount := sketch.HeapStats()
+		return stats{used, capacity, minCount}
+	}
+
+	if diff := cmp.Diff(stats{0, 3, 0}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	if diff := cmp.Diff(stats{2, 3, 5}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+	sketch.Add("c", 7)
+	sketch.Add("d", 8)
+	if diff := cmp.Diff(stats{3, 3, 7}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_WithMinCount(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0), topk.WithMinCount(10))
+	sketch.Add("a", 3)
+	sketch.Add("b", 9)
+	if items := sketch.SortedSlice(); len(items) != 0 {
+		t.Errorf("Expected no items below the minimum count in the top K, got %v", items)
+	}
+	if sketch.Query("a") || sketch.Query("b") {
+		t.Error("Expected items below the minimum count not to be in the top K")
+	}
+	if c := sketch.Count("b"); c != 9 {
+		t.Errorf("Expected Count(b) = 9 from the buckets, got %d", c)
+	}
+
+	// b enters the top K once its count reaches the threshold.
+	sketch.Incr("b")
+	expected := []heap.Item{{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: 10}}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_Remove(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+
+	sketch.Remove("a")
+	sketch.Remove("missing")
+	if sketch.Query("a") || sketch.Count("a") != 0 {
+		t.Errorf("Expected a to be removed, got Query = %v, Count = %d", sketch.Query("a"), sketch.Count("a"))
+	}
+	expected := []heap.Item{{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: 5}}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+	if total := sketch.TotalCount(); total != 5 {
+		t.Errorf("Expected a total count of 5, got %d", total)
+	}
+
+	// A removed item starts over when it is counted again.
+	sketch.Add("a", 1)
+	if c := sketch.Count("a"); c != 1 {
+		t.Errorf("Expected Count(a) = 1 after re-adding, got %d", c)
+	}
+}
+
+func TestSketch_WithHeapBucketSync(t *testing.T) {
+	for _, sync := range []bool{false, true} {
+		t.Run(fmt.Sprintf("Sync=%v", sync), func(t *testing.T) {
+			opts := []topk.Option{topk.WithWidth(1), topk.WithDepth(1), topk.WithDecay(1)}
+			if sync {
+				opts = append(opts, topk.WithHeapBucketSync())
+			}
+			sketch := topk.New(2, opts...)
+			sketch.Add("a", 2)
+			// With a decay of 1, b decrements a's only bucket on every collision and takes it over.
+			sketch.Add("b", 5)
+			if c := sketch.Buckets[0]; c.Fingerprint != topk.Fingerprint("b") {
+				t.Fatalf("Expected b to take over the only bucket, got %+v", c)
+			}
+
+			if got := sketch.Query("a"); got == sync {
+				t.Errorf("Expected Query(a) = %v, got %v", !sync, got)
+			}
+			if got := sketch.Query("b"); !got {
+				t.Error("Expected b to be in the top K")
+			}
+			expected := []heap.Item{{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: sketch.Buckets[0].Count}}
+			if !sync {
+				expected = append(expected, heap.Item{Fingerprint: topk.Fingerprint("a"), Item: "a", Count: 2})
+			}
+			if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+				t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSketch_WithOnPromoteOnEvict(t *testing.T) {
+	promoted := map[string]int{}
+	evicted := map[string]int{}
+	sketch := topk.New(2, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0),
+		topk.WithOnPromote(func(item string, count uint32) { promoted[item]++ }),
+		topk.WithOnEvict(func(item string, count uint32) { evicted[item]++ }),
+	)
+
+	sketch.Add("a", 5)
+	sketch.Add("b", 3)
+	for range 10 { // count updates of items in the top K
+		sketch.Incr("a")
+		sketch.Incr("b")
+	}
+	sketch.Add("c", 20) // replaces b
+	sketch.Add("d", 1)  // too small to enter
+
+	if diff := cmp.Diff(map[string]int{"a": 1, "b": 1, "c": 1}, promoted); diff != "" {
+		t.Errorf("Promotions mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(map[string]int{"b": 1}, evicted); diff != "" {
+		t.Errorf("Evictions mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_WithColdStartDecay(t *testing.T) {
+	for _, coldStart := range []bool{false, true} {
+		t.Run(fmt.Sprintf("ColdStartDecay=%v", coldStart), func(t *testing.T) {
+			opts := []topk.Option{topk.WithWidth(1024), topk.WithDepth(3), topk.WithRand(rand.New(rand.NewPCG(1, 2)))}
+			if coldStart {
+				opts = append(opts, topk.WithColdStartDecay(5))
+			}
+			sketch := topk.New(2, opts...)
+			sketch.Add("burst", 1000)
+			for range 100 {
+				sketch.Incr("steady1")
+				sketch.Incr("steady2")
+			}
+
+			// Without cold-start decay, the burst's full increment keeps it in the top K ahead of the steady items.
+			if got := sketch.Query("burst"); got == coldStart {
+				t.Errorf("Expected Query(burst) = %v, got %v", !coldStart, got)
+			}
+			if top := sketch.SortedSlice()[0].Item; coldStart == (top == "burst") {
+				t.Errorf("Unexpected top item %q", top)
+			}
+			if c := sketch.Count("burst"); coldStart && (c < 5 || c >= 100) {
+				t.Errorf("Expected the burst's count to be decayed to between the threshold and the steady counts, got %d", c)
+			}
+		})
+	}
+}
+
+func TestSketch_CountAndQuery(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(16), topk.WithDepth(2), topk.WithRand(rand.New(rand.NewPCG(1, 2))))
+	r := rand.New(rand.NewPCG(3, 4))
+	for range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", r.IntN(50)), uint32(1+r.IntN(5)))
+	}
+	for i := range 60 {
+		item := fmt.Sprintf("item%d", i)
+		count, inTopK := sketch.CountAndQuery(item)
+		if count != sketch.Count(item) || inTopK != sketch.Query(item) {
+			t.Errorf("CountAndQuery(%q) = (%d, %v), but Count = %d and Query = %v", item, count, inTopK, sketch.Count(item), sketch.Query(item))
+		}
+	}
+}
+
+func TestSketch_WithDecayFunc(t *testing.T) {
+	calls := 0
+	half := func(count uint32) float32 {
+		calls++
+		return 0.5
+	}
+	sketch := topk.New(2, topk.WithWidth(1), topk.WithDepth(1), topk.WithDecayFunc(half), topk.WithRand(rand.New(rand.NewPCG(1, 2))))
+	sketch.Add("a", 1000)
+	for range 200 {
+		sketch.Incr("b")
+	}
+
+	// With the default decay of 0.9, a counter of 1000 is practically never decremented;
+	// with a constant decay probability, about half of b's collisions decrement it.
+	b := sketch.Buckets[0]
+	if b.Fingerprint != topk.Fingerprint("a") || b.Count < 1000-130 || b.Count > 1000-70 {
+		t.Errorf("Expected a's counter to be decremented by about 100, got %+v", b)
+	}
+	if calls != 200 {
+		t.Errorf("Expected the decay function to be called once per collision, got %d calls", calls)
+	}
+}
+
+func TestSketch_Seq(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	sketch.Add("a", 3)
+	sketch.Add("b", 2)
+	sketch.Add("c", 1)
+
+	got := map[string]uint32{}
+	for item, count := range sketch.Seq() {
+		got[item] = count
+	}
+	if diff := cmp.Diff(map[string]uint32{"a": 3, "b": 2, "c": 1}, got); diff != "" {
+		t.Errorf("Seq mismatch (-want +got):\n%s", diff)
+	}
+
+	n := 0
+	for range sketch.Seq() {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Expected the iteration to stop after the first item, got %d items", n)
+	}
+}
+
+func TestSketch_SortedIter(t *testing.T) {
+	sketch := topk.New(10, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	for i := range 10 {
+		sketch.Add(fmt.Sprintf("item%d", i), uint32(1+(i*7)%10))
+	}
+
+	var got []heap.Item
+	sketch.SortedIter(func(item *heap.Item) bool {
+		got = append(got, *item)
+		return true
+	})
+	if diff := cmp.Diff(sketch.SortedSlice(), got); diff != "" {
+		t.Errorf("SortedIter mismatch (-want +got):\n%s", diff)
+	}
+
+	var first *heap.Item
+	sketch.SortedIter(func(item *heap.Item) bool {
+		first = item
+		return false
+	})
+	if first == nil || first.Count != 10 {
+		t.Errorf("Expected the first item to have the maximum count 10, got %+v", first)
+	}
+}
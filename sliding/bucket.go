@@ -9,6 +9,11 @@ type Bucket struct {
 	First  uint32
 	// CountsSum is the current sum of Counts
 	CountsSum uint32
+
+	// minIndex caches findNonzeroMinimumCount if minIndexValid is true.
+	// The zero value marks the cache as invalid, so that buckets that are decoded or modified directly re-compute it.
+	minIndex      uint32
+	minIndexValid bool
 }
 
 func (me *Bucket) tick() {
@@ -25,6 +30,53 @@ func (me *Bucket) tick() {
 	me.CountsSum -= me.Counts[last]
 	me.Counts[last] = 0
 	me.First = last
+	// The scan order of the remaining counts is unchanged, so the minimum only moves if it was the expired count.
+	if me.minIndex == last {
+		me.minIndexValid = false
+	}
+}
+
+// reset sets the bucket to hold only the given count in slot i.
+func (me *Bucket) reset(i, count uint32) {
+	clear(me.Counts)
+	me.Counts[i] = count
+	me.minIndex, me.minIndexValid = i, true
+}
+
+// clearCounts empties the bucket.
+func (me *Bucket) clearCounts() {
+	clear(me.Counts)
+	me.CountsSum = 0
+	me.Fingerprint = 0
+	me.minIndexValid = false
+}
+
+// incrementFirst adds the increment to the current tick's count.
+func (me *Bucket) incrementFirst(increment uint32) {
+	c := me.Counts[me.First] + increment
+	me.Counts[me.First] = c
+	if !me.minIndexValid {
+		return
+	}
+	switch {
+	case me.minIndex == me.First:
+		me.minIndexValid = false
+	// The current tick's count is scanned first, so it wins ties. A zero increment can leave it at zero, which is never the minimum.
+	case c != 0 && c <= me.Counts[me.minIndex]:
+		me.minIndex = me.First
+	}
+}
+
+// decrementMinimum decrements the smallest non-zero count, which must exist.
+func (me *Bucket) decrementMinimum() {
+	if !me.minIndexValid {
+		me.minIndex, me.minIndexValid = uint32(me.findNonzeroMinimumCount()), true
+	}
+	me.Counts[me.minIndex]--
+	// A decremented minimum stays the minimum unless it drops to zero.
+	if me.Counts[me.minIndex] == 0 {
+		me.minIndexValid = false
+	}
 }
 
 func (me *Bucket) findNonzeroMinimumCount() int {
@@ -50,3 +102,14 @@ func (me *Bucket) findNonzeroMinimumCount() int {
 	}
 	return int(countsMinIdx)
 }
+
+// sumAges returns the sum of the counts from `from` (inclusive) to `to` (exclusive) ticks ago,
+// where age 0 is the current tick.
+func (me *Bucket) sumAges(from, to int) uint32 {
+	var sum uint32
+	n := len(me.Counts)
+	for age := from; age < to; age++ {
+		sum += me.Counts[(int(me.First)+age)%n]
+	}
+	return sum
+}
@@ -0,0 +1,59 @@
+package sliding_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+	"github.com/keilerkonzept/topk/sliding"
+)
+
+func TestSketch_AddBytes(t *testing.T) {
+	sketch := sliding.New(2, 2)
+
+	// The same sequence as in TestSketchSlidingWindowDecay, through one reused buffer.
+	buf := make([]byte, 1)
+	add := func(item string, increment uint32) {
+		copy(buf, item)
+		sketch.AddBytes(buf, increment)
+	}
+	add("X", 3)
+	add("Y", 2)
+	add("Z", 1)
+
+	expected := []heap.Item{
+		{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 3},
+		{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
+	}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+
+	sketch.Tick()
+	sketch.Tick()
+	add("Y", 2)
+	add("Z", 2)
+	copy(buf, "Z")
+	sketch.IncrBytes(buf)
+	buf[0] = 0
+
+	expected = []heap.Item{
+		{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 3},
+		{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
+	}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Error(diff)
+	}
+	for _, item := range expected {
+		if !sketch.QueryBytes([]byte(item.Item)) {
+			t.Errorf("Expected QueryBytes(%s) = true", item.Item)
+		}
+		if c := sketch.CountBytes([]byte(item.Item)); c != item.Count {
+			t.Errorf("Expected CountBytes(%s) = %d, got %d", item.Item, item.Count, c)
+		}
+	}
+	if sketch.QueryBytes([]byte("X")) {
+		t.Error("Expected X to have left the window")
+	}
+}
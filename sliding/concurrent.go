@@ -0,0 +1,130 @@
+package sliding
+
+import (
+	"sync"
+	"time"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// Concurrent wraps a sliding [Sketch] for concurrent use, guarding it with a read-write lock.
+type Concurrent struct {
+	mu     sync.RWMutex
+	sketch *Sketch
+}
+
+// NewConcurrent returns a concurrency-safe wrapper of the given sketch.
+// The sketch must not be accessed directly while the wrapper is in use.
+func NewConcurrent(sketch *Sketch) *Concurrent {
+	return &Concurrent{sketch: sketch}
+}
+
+// Incr counts a single instance of the given item.
+func (me *Concurrent) Incr(item string) bool {
+	return me.Add(item, 1)
+}
+
+// Add increments the given item's count by the given increment.
+// Returns whether the item is in the top K.
+func (me *Concurrent) Add(item string, increment uint32) bool {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.sketch.Add(item, increment)
+}
+
+// Count returns the estimated count of the given item.
+func (me *Concurrent) Count(item string) uint32 {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	return me.sketch.Count(item)
+}
+
+// Query returns whether the given item is in the top K items by count.
+func (me *Concurrent) Query(item string) bool {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	return me.sketch.Query(item)
+}
+
+// SortedSlice returns the top K items as a sorted slice.
+func (me *Concurrent) SortedSlice() []heap.Item {
+	me.mu.RLock()
+	defer me.mu.RUnlock()
+	return me.sketch.SortedSlice()
+}
+
+// Drain returns the top K items as a sorted slice and then resets the sketch, see [Sketch.Drain].
+// Both happen under a single write lock, so no Add is lost between the snapshot and the reset.
+func (me *Concurrent) Drain() []heap.Item {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	return me.sketch.Drain()
+}
+
+// Tick advances time by one unit (of the N units in a window)
+func (me *Concurrent) Tick() { me.Ticks(1) }
+
+// Ticks advances time by n units (of the N units in a window)
+func (me *Concurrent) Ticks(n int) {
+	me.mu.Lock()
+	defer me.mu.Unlock()
+	me.sketch.Ticks(n)
+}
+
+// TickEvery starts a goroutine that calls [Concurrent.Tick] every `d`, and returns a function that stops it.
+// The stop function waits for the goroutine to exit and is safe to call more than once.
+func (me *Concurrent) TickEvery(d time.Duration) (stop func()) {
+	ticker := time.NewTicker(d)
+	stopTicks := me.TickOn(ticker.C)
+	return func() {
+		ticker.Stop()
+		stopTicks()
+	}
+}
+
+// TickOn is like [Concurrent.TickEvery], but calls [Concurrent.Tick] once for each value received from the channel,
+// e.g. from a [time.Ticker] or a fake clock. The goroutine exits when the channel is closed.
+func (me *Concurrent) TickOn(c <-chan time.Time) (stop func()) {
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		for {
+			select {
+			case <-done:
+				return
+			case _, ok := <-c:
+				if !ok {
+					return
+				}
+				me.Tick()
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-exited
+	}
+}
+
+// AutoTick is a [Concurrent] sketch that ticks itself on a [time.Ticker], see [NewAutoTick].
+type AutoTick struct {
+	*Concurrent
+
+	stop func()
+}
+
+// NewAutoTick returns a concurrency-safe sliding sketch (see [New]) that calls [Concurrent.Tick] every `tickInterval`
+// from a background goroutine, so that the window advances with wall-clock time.
+// The ticker drops ticks for a slow receiver rather than queueing them, so a GC pause delays a tick instead of causing a burst of ticks.
+//
+// Callers must call [AutoTick.Stop] when they are done with the sketch, since the goroutine and ticker are otherwise leaked.
+func NewAutoTick(k, windowSize int, tickInterval time.Duration, opts ...Option) *AutoTick {
+	c := NewConcurrent(New(k, windowSize, opts...))
+	return &AutoTick{Concurrent: c, stop: c.TickEvery(tickInterval)}
+}
+
+// Stop stops the automatic ticking and waits for the ticking goroutine to exit.
+// The sketch remains usable (and can still be ticked manually) afterwards. Stop is safe to call more than once.
+func (me *AutoTick) Stop() { me.stop() }
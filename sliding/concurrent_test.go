@@ -0,0 +1,135 @@
+package sliding_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/keilerkonzept/topk/sliding"
+)
+
+func TestConcurrent_TickOn(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3))
+	c := sliding.NewConcurrent(sketch)
+	c.Add("a", 5)
+
+	clock := make(chan time.Time)
+	stop := c.TickOn(clock)
+	for range 3 {
+		clock <- time.Time{}
+	}
+	stop()
+	stop() // safe to call twice
+
+	// Each tick ages a quarter of the window, so after 3 ticks the count is still in the window.
+	if n := c.Count("a"); n != 5 {
+		t.Errorf("Expected Count(a) = 5 after 3 of 4 ticks, got %d", n)
+	}
+	c.Tick()
+	if n := c.Count("a"); n != 0 {
+		t.Errorf("Expected Count(a) = 0 after 4 ticks, got %d", n)
+	}
+
+	select {
+	case clock <- time.Time{}:
+		t.Error("Expected no ticks to be consumed after stop")
+	default:
+	}
+}
+
+func TestConcurrent_TickOn_Closed(t *testing.T) {
+	c := sliding.NewConcurrent(sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3)))
+	c.Add("a", 5)
+
+	clock := make(chan time.Time)
+	stop := c.TickOn(clock)
+	clock <- time.Time{}
+	close(clock)
+	time.Sleep(10 * time.Millisecond) // time for a goroutine that misreads the closed channel to tick the count away
+	stop()
+
+	// A closed channel must not be read as an endless stream of ticks.
+	if n := c.Count("a"); n != 5 {
+		t.Errorf("Expected Count(a) = 5 after a single tick, got %d", n)
+	}
+}
+
+func TestConcurrent_TickEvery(t *testing.T) {
+	c := sliding.NewConcurrent(sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3)))
+	stop := c.TickEvery(time.Millisecond)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 1000 {
+				c.Incr("a")
+				_ = c.SortedSlice()
+			}
+		}()
+	}
+	wg.Wait()
+	stop()
+	stop()
+}
+
+func TestNewAutoTick(t *testing.T) {
+	s := sliding.NewAutoTick(3, 4, time.Millisecond, sliding.WithWidth(1024), sliding.WithDepth(3))
+	defer s.Stop()
+	s.Add("a", 5)
+
+	// The count ages out of the window after 4 ticks.
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Count("a") != 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected Count(a) to age out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	s.Stop()
+	s.Stop() // safe to call twice
+
+	s.Add("b", 5)
+	time.Sleep(10 * time.Millisecond)
+	if n := s.Count("b"); n != 5 {
+		t.Errorf("Expected Count(b) = 5 without ticks after Stop, got %d", n)
+	}
+}
+
+func TestConcurrent_Drain(t *testing.T) {
+	c := sliding.NewConcurrent(sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3)))
+
+	const writers, adds = 4, 1000
+	var wg sync.WaitGroup
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range adds {
+				c.Incr("a")
+			}
+		}()
+	}
+
+	// Every Add is counted in exactly one drained snapshot.
+	var drained uint32
+	done := make(chan struct{})
+	go func() { wg.Wait(); close(done) }()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		for _, item := range c.Drain() {
+			drained += item.Count
+		}
+	}
+	if drained != writers*adds {
+		t.Errorf("Expected %d drained counts, got %d", writers*adds, drained)
+	}
+	if n := c.Count("a"); n != 0 {
+		t.Errorf("Expected Count(a) = 0 after the last drain, got %d", n)
+	}
+}
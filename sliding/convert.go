@@ -0,0 +1,42 @@
+package sliding
+
+import (
+	"fmt"
+
+	"github.com/keilerkonzept/topk"
+)
+
+// FromSketch returns a sliding top-k sketch with the given `windowSize` (in ticks) whose current tick is seeded from the plain sketch `s`:
+// each bucket takes over the fingerprint and count of the corresponding bucket of `s`, and the top-K heap takes over the items of `s`.
+// The seeded counts age out of the window like any others, once `windowSize` ticks have passed.
+//
+// The K, width, depth, decay, and hash functions (see [topk.Sketch.Hasher]) default to those of `s`; the options are applied on top of these defaults.
+// Panics if the options change the width, depth, or decay, or if `s` has per-row widths,
+// since the buckets of `s` can't be mapped onto a different geometry.
+func FromSketch(s *topk.Sketch, windowSize int, opts ...Option) *Sketch {
+	if s.RowWidths != nil {
+		panic("sliding: FromSketch: per-row widths are not supported")
+	}
+	defaults := []Option{WithWidth(s.Width), WithDepth(s.Depth), WithDecay(s.Decay), WithHasher(s.Hasher())}
+	out := New(s.K, windowSize, append(defaults, opts...)...)
+	if out.Width != s.Width || out.Depth != s.Depth || out.Decay != s.Decay {
+		panic(fmt.Sprintf("sliding: FromSketch: incompatible geometry: width %d, depth %d, decay %v != width %d, depth %d, decay %v",
+			out.Width, out.Depth, out.Decay, s.Width, s.Depth, s.Decay))
+	}
+
+	for i, b := range s.Buckets {
+		if b.Count == 0 {
+			continue
+		}
+		ob := &out.Buckets[i]
+		ob.Fingerprint = b.Fingerprint
+		ob.Counts[ob.First] = b.Count
+		ob.CountsSum = b.Count
+	}
+	for _, item := range s.Heap.Items {
+		if item.Count > 0 {
+			out.Heap.Update(item.Item, item.Fingerprint, item.Count)
+		}
+	}
+	return out
+}
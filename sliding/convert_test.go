@@ -0,0 +1,45 @@
+package sliding_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/sliding"
+)
+
+func TestFromSketch(t *testing.T) {
+	plain := topk.New(5, topk.WithWidth(1024), topk.WithDepth(3))
+	for i := range 1000 {
+		plain.Add(fmt.Sprintf("item%d", i%20), uint32(1+i%20))
+	}
+
+	sketch := sliding.FromSketch(plain, 3)
+
+	if diff := cmp.Diff(plain.SortedSlice(), sketch.SortedSlice()); diff != "" {
+		t.Errorf("Top K mismatch (-plain +sliding):\n%s", diff)
+	}
+	for i := range 20 {
+		item := fmt.Sprintf("item%d", i)
+		if expected, actual := plain.Count(item), sketch.Count(item); actual != expected {
+			t.Errorf("Expected Count(%s) = %d, got %d", item, expected, actual)
+		}
+	}
+
+	sketch.Add("new", 1)
+	sketch.Ticks(3)
+	if items := sketch.SortedSlice(); len(items) != 0 {
+		t.Errorf("Expected the seeded counts to age out of the window, got %v", items)
+	}
+}
+
+func TestFromSketch_IncompatibleGeometry(t *testing.T) {
+	plain := topk.New(5, topk.WithWidth(1024), topk.WithDepth(3))
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a different width")
+		}
+	}()
+	sliding.FromSketch(plain, 3, sliding.WithWidth(512))
+}
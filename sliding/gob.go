@@ -0,0 +1,84 @@
+package sliding
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"slices"
+
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/heap"
+)
+
+// gobSketch has the fields of [Sketch], but not its methods, so that it is encoded by gob's default struct encoding.
+type gobSketch Sketch
+
+// GobEncode implements [gob.GobEncoder]. It encodes all exported fields, including each bucket's count history.
+func (me *Sketch) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode((*gobSketch)(me)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// GobDecode implements [gob.GobDecoder]. It validates the decoded buckets,
+// recomputes the decay LUT, and rebuilds the heap's index.
+// Options that are not serializable ([WithTickHook] and [WithHasher]) are kept from the receiver.
+// Returns an error wrapping [topk.ErrInvalidEncoding] if the parameters are degenerate or the buckets' histories are inconsistent.
+func (me *Sketch) GobDecode(data []byte) error {
+	var decoded gobSketch
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&decoded); err != nil {
+		return err
+	}
+	if err := decoded.validate(); err != nil {
+		return err
+	}
+	decoded.tickHook = me.tickHook
+	decoded.fingerprintFunc, decoded.bucketIndexFunc = me.fingerprintFunc, me.bucketIndexFunc
+	*me = Sketch(decoded)
+	me.initDecayLUT()
+	if me.Heap == nil {
+		me.Heap = heap.NewMin(me.K)
+	}
+	me.Heap.Items = slices.Grow(me.Heap.Items, max(0, me.Heap.K-len(me.Heap.Items)))
+	me.Heap.Index = make(map[string]int, len(me.Heap.Items))
+	me.Heap.StoredKeysBytes = 0
+	for i, item := range me.Heap.Items {
+		me.Heap.Index[item.Item] = i
+		me.Heap.StoredKeysBytes += len(item.Item)
+	}
+	return nil
+}
+
+func (me *gobSketch) validate() error {
+	if me.Width <= 0 || me.WindowSize <= 0 {
+		return fmt.Errorf("%w: width %d and window size %d must be positive", topk.ErrInvalidEncoding, me.Width, me.WindowSize)
+	}
+	if len(me.DecayLUT) < 2 {
+		return fmt.Errorf("%w: decay LUT size %d, expected at least 2", topk.ErrInvalidEncoding, len(me.DecayLUT))
+	}
+	if len(me.Buckets) != me.Width*me.Depth {
+		return fmt.Errorf("%w: %d buckets for width %d and depth %d", topk.ErrInvalidEncoding, len(me.Buckets), me.Width, me.Depth)
+	}
+	for i := range me.Buckets {
+		b := &me.Buckets[i]
+		if len(b.Counts) != me.BucketHistoryLength {
+			return fmt.Errorf("%w: bucket %d has a history of length %d, expected %d", topk.ErrInvalidEncoding, i, len(b.Counts), me.BucketHistoryLength)
+		}
+		if int(b.First) >= len(b.Counts) {
+			return fmt.Errorf("%w: bucket %d has first index %d out of range", topk.ErrInvalidEncoding, i, b.First)
+		}
+		var sum uint32
+		for _, c := range b.Counts {
+			sum += c
+		}
+		if sum != b.CountsSum {
+			return fmt.Errorf("%w: bucket %d has counts sum %d, expected %d", topk.ErrInvalidEncoding, i, b.CountsSum, sum)
+		}
+	}
+	if me.NextBucketToExpireIndex < 0 || me.NextBucketToExpireIndex >= max(1, len(me.Buckets)) {
+		return fmt.Errorf("%w: next bucket to expire %d out of range", topk.ErrInvalidEncoding, me.NextBucketToExpireIndex)
+	}
+	return nil
+}
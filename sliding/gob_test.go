@@ -0,0 +1,103 @@
+package sliding_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/sliding"
+)
+
+func TestSketch_Gob(t *testing.T) {
+	sketch := sliding.New(5, 4, sliding.WithWidth(64), sliding.WithDepth(3))
+	for tick := range 3 {
+		for i := range 100 {
+			sketch.Add(fmt.Sprintf("item%d", (i+tick)%15), uint32(1+i%4))
+		}
+		sketch.Tick()
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(sketch); err != nil {
+		t.Fatal(err)
+	}
+	var decoded sliding.Sketch
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff(sketch.SortedSlice(), decoded.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-original +decoded):\n%s", diff)
+	}
+	if diff := cmp.Diff(sketch.Heap, decoded.Heap); diff != "" {
+		t.Errorf("Heap mismatch (-original +decoded):\n%s", diff)
+	}
+	if diff := cmp.Diff(sketch.DecayLUT, decoded.DecayLUT); diff != "" {
+		t.Errorf("DecayLUT mismatch (-original +decoded):\n%s", diff)
+	}
+
+	// The decoded sketch keeps aging like the original.
+	sketch.Tick()
+	decoded.Tick()
+	if diff := cmp.Diff(sketch.SortedSlice(), decoded.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch after a tick (-original +decoded):\n%s", diff)
+	}
+}
+
+func TestSketch_GobDecode_Invalid(t *testing.T) {
+	for name, corrupt := range map[string]func(*sliding.Sketch){
+		"history length":  func(s *sliding.Sketch) { s.BucketHistoryLength++ },
+		"counts sum":      func(s *sliding.Sketch) { s.Buckets[0].CountsSum++ },
+		"bucket count":    func(s *sliding.Sketch) { s.Buckets = s.Buckets[1:] },
+		"empty decay LUT": func(s *sliding.Sketch) { s.DecayLUT = nil },
+		"zero window":     func(s *sliding.Sketch) { s.WindowSize = 0 },
+		"zero width":      func(s *sliding.Sketch) { s.Width, s.Depth, s.Buckets = 0, 0, nil },
+	} {
+		t.Run(name, func(t *testing.T) {
+			sketch := sliding.New(3, 4, sliding.WithWidth(8), sliding.WithDepth(2))
+			sketch.Add("a", 3)
+			corrupt(sketch)
+			data, err := sketch.GobEncode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded sliding.Sketch
+			if err := decoded.GobDecode(data); !errors.Is(err, topk.ErrInvalidEncoding) {
+				t.Errorf("Expected ErrInvalidEncoding, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSketch_Gob_WithHasher(t *testing.T) {
+	fingerprint := func(item string) uint32 { return uint32(item[0]) }
+	bucketIndex := func(item string, row, width int) int { return row*width + int(item[0])%width }
+	newSketch := func() *sliding.Sketch {
+		return sliding.New(3, 4, sliding.WithWidth(64), sliding.WithDepth(2), sliding.WithHasher(fingerprint, bucketIndex))
+	}
+	sketch := newSketch()
+	sketch.Add("a", 3)
+	sketch.Add("b", 5)
+
+	data, err := sketch.GobEncode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := newSketch()
+	if err := decoded.GobDecode(data); err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range []string{"a", "b"} {
+		if expected, actual := sketch.Count(item), decoded.Count(item); actual != expected {
+			t.Errorf("Expected Count(%s) = %d after decoding with the receiver's hasher, got %d", item, expected, actual)
+		}
+	}
+	decoded.Add("a", 2)
+	if c := decoded.Count("a"); c != 5 {
+		t.Errorf("Expected Count(a) = 5, got %d", c)
+	}
+}
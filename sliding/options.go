@@ -1,5 +1,11 @@
 package sliding
 
+import (
+	"time"
+
+	"github.com/keilerkonzept/topk/heap"
+)
+
 type Option func(*Sketch)
 
 // WithDepth sets the depth (number of hash functions) of a sketch.
@@ -24,3 +30,36 @@ func WithDecayLUTSize(n int) Option {
 func WithBucketHistoryLength(n int) Option {
 	return func(s *Sketch) { s.BucketHistoryLength = n }
 }
+
+// WithTickDuration enables timestamp mode: [Sketch.AddAt] and [Sketch.AdvanceTo] apply one tick per elapsed duration `d`,
+// measured from the first timestamp the sketch sees, which spares callers mapping wall-clock time onto [Sketch.Tick] calls.
+func WithTickDuration(d time.Duration) Option {
+	return func(s *Sketch) { s.TickDuration = d }
+}
+
+// WithTickHook sets a callback that is invoked at the end of each [Sketch.Tick] (or [Sketch.Ticks]) call,
+// with the window's top K items after aging as a sorted slice (see [Sketch.SortedSlice]).
+// The callback runs synchronously and may retain the slice.
+func WithTickHook(hook func(topK []heap.Item)) Option {
+	return func(s *Sketch) { s.tickHook = hook }
+}
+
+// WithTopKHistory keeps snapshots of the top-K item strings after each of the last n ticks,
+// which enables leaderboard volatility analysis via [Sketch.Churn].
+// Each snapshot costs a slice of up to K strings (sharing the heap's string data).
+func WithTopKHistory(n int) Option {
+	return func(s *Sketch) { s.TopKHistoryLength = n }
+}
+
+// WithHasher replaces the hash functions of a sketch: fingerprint computes an item's fingerprint (by default [topk.Fingerprint]),
+// and bucketIndex computes the index in the sketch's buckets of an item's bucket in the given row (by default [topk.BucketIndex]),
+// which must be in `[row*width, (row+1)*width)`.
+//
+// Sketches that are merged or compared must use the same hash functions, which can't be checked.
+// The functions are not serialized: [Sketch.GobDecode] keeps the receiver's hash functions, so decode into a sketch created with the same option.
+func WithHasher(fingerprint func(item string) uint32, bucketIndex func(item string, row, width int) int) Option {
+	return func(s *Sketch) {
+		s.fingerprintFunc = fingerprint
+		s.bucketIndexFunc = bucketIndex
+	}
+}
@@ -4,16 +4,22 @@
 package sliding
 
 import (
+	"cmp"
+	"fmt"
+	"iter"
 	"math"
 	"math/rand/v2"
 	"slices"
-	"sort"
+	"strings"
+	"time"
 
 	"github.com/keilerkonzept/topk"
 	"github.com/keilerkonzept/topk/heap"
 	"github.com/keilerkonzept/topk/internal/sizeof"
 )
 
+const defaultDecayLUTSize = 256
+
 // Sketch is a sliding-window top-k sketch.
 // The entire structure is serializable using any serialization method - all fields and sub-structs are exported and can be reasonably serialized.
 type Sketch struct {
@@ -31,8 +37,22 @@ type Sketch struct {
 	// Index of the next bucket to expire.
 	NextBucketToExpireIndex int
 
+	// Wall-clock duration of a tick for timestamped adds, see [WithTickDuration] and [Sketch.AddAt]. Zero disables timestamp mode.
+	TickDuration time.Duration
+	// Start of the current tick in Unix nanoseconds, set by the first timestamped add. Zero if there has been none.
+	TickStartUnixNano int64
+
 	Buckets []Bucket  // Sketch counters.
 	Heap    *heap.Min // Top-K min-heap.
+
+	// Number of per-tick top-K snapshots to keep, see [WithTopKHistory].
+	TopKHistoryLength int
+	// Top-K item strings after each of the last TopKHistoryLength ticks, oldest first.
+	TopKHistory [][]string
+
+	tickHook        func(topK []heap.Item)                // Optional callback at the end of each [Sketch.Ticks], see [WithTickHook].
+	fingerprintFunc func(item string) uint32              // Fingerprint hash, see [WithHasher]. Nil means [topk.Fingerprint].
+	bucketIndexFunc func(item string, row, width int) int // Bucket index hash, see [WithHasher]. Nil means [topk.BucketIndex].
 }
 
 // New returns a sliding top-k sketch with the given `k` (number of top items to keep) and `windowSize` (in ticks).`
@@ -62,7 +82,7 @@ func New(k, windowSize int, opts ...Option) *Sketch {
 
 	if len(out.DecayLUT) == 0 {
 		// if not specified, default to 256
-		out.DecayLUT = make([]float32, 256)
+		out.DecayLUT = make([]float32, defaultDecayLUTSize)
 	}
 
 	if out.BucketHistoryLength < 1 {
@@ -92,11 +112,71 @@ func (me *Sketch) initBuckets() {
 	}
 }
 
+// Clone returns a deep copy of the sketch that shares no buckets, heap, look-up tables, or top-K history with it,
+// e.g. for serving a snapshot from a read replica while the original keeps counting.
+// The clone shares the tick hook set by [WithTickHook] and the hash functions set by [WithHasher] with the original.
+func (me *Sketch) Clone() *Sketch {
+	out := *me
+	out.DecayLUT = slices.Clone(me.DecayLUT)
+	out.Buckets = slices.Clone(me.Buckets)
+	counts := make([]uint32, 0, len(me.Buckets)*me.BucketHistoryLength)
+	for i := range out.Buckets {
+		b := &out.Buckets[i]
+		counts = append(counts, b.Counts...)
+		b.Counts = counts[len(counts)-len(b.Counts) : len(counts) : len(counts)]
+	}
+	out.Heap = me.Heap.Clone()
+	if me.TopKHistory != nil {
+		out.TopKHistory = make([][]string, len(me.TopKHistory))
+		for i, snapshot := range me.TopKHistory {
+			out.TopKHistory[i] = slices.Clone(snapshot)
+		}
+	}
+	return &out
+}
+
+// Equal returns whether the sketches have the same parameters, buckets (including their count histories),
+// top-K heap items (in any heap order), and top-K history, e.g. for checking that a sketch survives an encoding round trip.
+// It ignores the decay LUT, which is derived from the decay, and can't compare the tick hooks and hash functions.
+func (me *Sketch) Equal(other *Sketch) bool {
+	return me.K == other.K &&
+		me.Width == other.Width &&
+		me.Depth == other.Depth &&
+		me.WindowSize == other.WindowSize &&
+		me.BucketHistoryLength == other.BucketHistoryLength &&
+		me.Decay == other.Decay &&
+		me.NextBucketToExpireIndex == other.NextBucketToExpireIndex &&
+		me.TickDuration == other.TickDuration &&
+		me.TickStartUnixNano == other.TickStartUnixNano &&
+		slices.EqualFunc(me.Buckets, other.Buckets, func(a, b Bucket) bool {
+			return a.Fingerprint == b.Fingerprint && a.First == b.First && a.CountsSum == b.CountsSum && slices.Equal(a.Counts, b.Counts)
+		}) &&
+		me.Heap.Equal(other.Heap) &&
+		me.TopKHistoryLength == other.TopKHistoryLength &&
+		slices.EqualFunc(me.TopKHistory, other.TopKHistory, slices.Equal)
+}
+
 // SizeBytes returns the current size of the sketch in bytes.
 func (me *Sketch) SizeBytes() int {
-	bucketsSize := (sizeofBucketStruct + sizeof.UInt32*me.BucketHistoryLength) * len(me.Buckets)
-	heapSize := me.Heap.SizeBytes()
-	decayTableSize := len(me.DecayLUT) * sizeof.Float32
+	return sizeBytes(len(me.Buckets), me.BucketHistoryLength, len(me.DecayLUT), me.Heap.SizeBytes())
+}
+
+// EstimateSizeBytes returns the size in bytes that [Sketch.SizeBytes] reports for an empty sketch with the given parameters,
+// without allocating the sketch. A decayLUTSize of zero means the default LUT size used by [New].
+//
+// The bucketHistoryLength is used as given, except that it is raised to 1 as in [New].
+// Since the window size is not known here, the caller has to apply the clamping to the window size themselves.
+func EstimateSizeBytes(k, width, depth, decayLUTSize, bucketHistoryLength int) int {
+	if decayLUTSize == 0 {
+		decayLUTSize = defaultDecayLUTSize
+	}
+	bucketHistoryLength = max(1, bucketHistoryLength)
+	return sizeBytes(width*depth, bucketHistoryLength, decayLUTSize, heap.EstimateSizeBytes(k))
+}
+
+func sizeBytes(numBuckets, bucketHistoryLength, decayLUTSize, heapSize int) int {
+	bucketsSize := (sizeofBucketStruct + sizeof.UInt32*bucketHistoryLength) * numBuckets
+	decayTableSize := decayLUTSize * sizeof.Float32
 	return sizeofSketchStruct +
 		bucketsSize +
 		heapSize +
@@ -126,6 +206,129 @@ func (me *Sketch) Ticks(n int) {
 	}
 	me.NextBucketToExpireIndex = tick
 	me.recountHeapItems()
+	if me.TopKHistoryLength > 0 {
+		me.recordTopKHistory(n)
+	}
+	if me.tickHook != nil {
+		me.tickHook(me.SortedSlice())
+	}
+}
+
+// AddAt is like [Sketch.Add], but first advances the window to the given time (see [Sketch.AdvanceTo]),
+// so that the window is driven by event timestamps instead of calls to [Sketch.Tick].
+// Without a tick duration (see [WithTickDuration]), AddAt is the same as Add.
+func (me *Sketch) AddAt(item string, increment uint32, ts time.Time) bool {
+	me.AdvanceTo(ts)
+	return me.Add(item, increment)
+}
+
+// AdvanceTo applies the ticks (see [Sketch.Ticks]) of the tick durations that have fully elapsed between the start of the current tick and the given time,
+// e.g. to age the window before querying it after a quiet period. The first call only sets the start of the current tick.
+//
+// Timestamps before the end of the current tick, including out-of-order ones, don't advance the window,
+// so late events are counted in the current tick. If more than a window's worth of ticks has elapsed, only a window's worth is applied,
+// which already expires all counts. AdvanceTo is a no-op without a tick duration (see [WithTickDuration]).
+func (me *Sketch) AdvanceTo(ts time.Time) {
+	if me.TickDuration <= 0 {
+		return
+	}
+	now := ts.UnixNano()
+	if me.TickStartUnixNano == 0 {
+		me.TickStartUnixNano = now
+		return
+	}
+	elapsed := now - me.TickStartUnixNano
+	if elapsed < int64(me.TickDuration) {
+		return
+	}
+	n := elapsed / int64(me.TickDuration)
+	me.TickStartUnixNano += n * int64(me.TickDuration)
+	me.Ticks(int(min(n, int64(me.WindowSize))))
+}
+
+// recordTopKHistory appends a snapshot of the current top-K items for each of the n ticks that have just passed.
+// The intermediate states of a multi-tick advance are not computed, so all n snapshots hold the state after the last tick.
+func (me *Sketch) recordTopKHistory(n int) {
+	snapshot := make([]string, 0, len(me.Heap.Items))
+	for item := range me.Iter {
+		snapshot = append(snapshot, item.Item)
+	}
+	for range min(n, me.TopKHistoryLength) {
+		me.TopKHistory = append(me.TopKHistory, snapshot)
+	}
+	if excess := len(me.TopKHistory) - me.TopKHistoryLength; excess > 0 {
+		me.TopKHistory = slices.Delete(me.TopKHistory, 0, excess)
+	}
+}
+
+// Churn returns the fraction of the current top-K items that were not in the top K `ticksAgo` ticks ago,
+// where a `ticksAgo` of 0 refers to the state right after the most recent tick.
+// It is 0 for a stable leaderboard and 1 if all leaders have been replaced; it is 0 if the top K is empty.
+//
+// Churn requires [WithTopKHistory]; it panics if `ticksAgo` is not less than the number of recorded snapshots.
+func (me *Sketch) Churn(ticksAgo int) float64 {
+	if ticksAgo < 0 || ticksAgo >= len(me.TopKHistory) {
+		panic(fmt.Sprintf("sliding: Churn: no top-K snapshot from %d ticks ago (have %d)", ticksAgo, len(me.TopKHistory)))
+	}
+	snapshot := me.TopKHistory[len(me.TopKHistory)-1-ticksAgo]
+	var current, replaced int
+	for item := range me.Iter {
+		current++
+		if !slices.Contains(snapshot, item.Item) {
+			replaced++
+		}
+	}
+	if current == 0 {
+		return 0
+	}
+	return float64(replaced) / float64(current)
+}
+
+// TickTotals returns the total count in each of the window's tick slots, ordered from oldest to newest.
+// The returned slice has [Sketch.BucketHistoryLength] entries.
+//
+// The totals are approximate: every item is counted once per row of the sketch, so the per-slot sums over all buckets are divided by the depth.
+// Counts lost to collisions (decayed counters) are missing from the totals, and
+// if the bucket history is shorter than the window, each slot aggregates several ticks.
+func (me *Sketch) TickTotals() []uint32 {
+	d := me.BucketHistoryLength
+	sums := make([]uint64, d)
+	for i := range me.Buckets {
+		b := &me.Buckets[i]
+		if b.CountsSum == 0 {
+			continue
+		}
+		// b.Counts[b.First] is the newest slot, b.Counts[b.First-1] (wrapping around) the oldest.
+		j := int(b.First)
+		for age := range d {
+			sums[d-1-age] += uint64(b.Counts[j])
+			j++
+			if j == d {
+				j = 0
+			}
+		}
+	}
+	out := make([]uint32, d)
+	for i, sum := range sums {
+		out[i] = uint32(sum / uint64(me.Depth))
+	}
+	return out
+}
+
+// fingerprint returns the item's fingerprint.
+func (me *Sketch) fingerprint(item string) uint32 {
+	if me.fingerprintFunc == nil {
+		return topk.Fingerprint(item)
+	}
+	return me.fingerprintFunc(item)
+}
+
+// bucketIndex returns the index in Buckets of the item's bucket in the given row.
+func (me *Sketch) bucketIndex(item string, row int) int {
+	if me.bucketIndexFunc == nil {
+		return topk.BucketIndex(item, row, me.Width)
+	}
+	return me.bucketIndexFunc(item, row, me.Width)
 }
 
 // Count returns the estimated count of the given item.
@@ -137,11 +340,25 @@ func (me *Sketch) Count(item string) uint32 {
 		}
 	}
 
-	fingerprint := topk.Fingerprint(item)
+	return me.bucketCount(item)
+}
+
+// CountAndQuery returns the estimated count of the given item (see [Sketch.Count]) and whether it is in the top K (see [Sketch.Query]),
+// with a single heap lookup.
+func (me *Sketch) CountAndQuery(item string) (count uint32, inTopK bool) {
+	if i := me.Heap.Find(item); i >= 0 {
+		return me.Heap.Items[i].Count, true
+	}
+	return me.bucketCount(item), false
+}
+
+// bucketCount returns the maximum window count among the item's buckets that hold its fingerprint.
+func (me *Sketch) bucketCount(item string) uint32 {
+	fingerprint := me.fingerprint(item)
 	var maxSum uint32
 
 	for i := range me.Depth {
-		b := &me.Buckets[topk.BucketIndex(item, i, me.Width)]
+		b := &me.Buckets[me.bucketIndex(item, i)]
 		if b.Fingerprint != fingerprint {
 			continue
 		}
@@ -151,6 +368,58 @@ func (me *Sketch) Count(item string) uint32 {
 	return maxSum
 }
 
+// itemBucket returns the bucket with the item's fingerprint and the largest count, or nil if no bucket holds the item's fingerprint.
+func (me *Sketch) itemBucket(item string) *Bucket {
+	fingerprint := me.fingerprint(item)
+	var out *Bucket
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint != fingerprint || b.CountsSum == 0 {
+			continue
+		}
+		if out == nil || b.CountsSum > out.CountsSum {
+			out = b
+		}
+	}
+	return out
+}
+
+// Trend compares the item's count in the recent half of the window with its count in the older half.
+// It returns `(recent - older) / (older + 1)`: positive values mean the item's count is rising, negative values that it is falling.
+// If the bucket history length is odd, the middle slot is counted as recent.
+//
+// The counts are taken from the item's bucket with the largest count.
+// Since buckets are shared between items, counts lost to collisions (and, rarely, counts of colliding items with equal fingerprints) distort the trend.
+// The trend is only meaningful for a bucket history length of at least 2.
+func (me *Sketch) Trend(item string) float64 {
+	b := me.itemBucket(item)
+	if b == nil {
+		return 0
+	}
+	d := me.BucketHistoryLength
+	mid := d - d/2
+	recent := float64(b.sumAges(0, mid))
+	older := float64(b.sumAges(mid, d))
+	return (recent - older) / (older + 1)
+}
+
+// Spiking returns whether the item's count in the current tick exceeds `factor` times its average count per tick over the rest of the window,
+// e.g. for alerting on items that have just gone viral. An item that has been counted only in the current tick is spiking for any factor.
+//
+// Like [Sketch.Trend], it uses the item's bucket with the largest count, so it is sensitive to bucket sharing:
+// counts lost to collisions lower the average of the older ticks, which makes spikes more likely to be reported for items in contested buckets.
+// Spiking is always false for a bucket history length of less than 2, and if the history is shorter than the window, each slot aggregates several ticks.
+func (me *Sketch) Spiking(item string, factor float64) bool {
+	b := me.itemBucket(item)
+	d := me.BucketHistoryLength
+	if b == nil || d < 2 {
+		return false
+	}
+	latest := float64(b.sumAges(0, 1))
+	average := float64(b.sumAges(1, d)) / float64(d-1)
+	return latest > factor*average
+}
+
 func (me *Sketch) recountHeapItems() {
 	// recompute each heap item's count from its buckets,
 	// then re-initialize the heap.
@@ -163,11 +432,10 @@ func (me *Sketch) recountHeapItems() {
 		}
 		fingerprint := hb.Fingerprint
 		item := hb.Item
-		width := me.Width
 		var maxSum uint32
 
 		for i := range me.Depth {
-			b := &me.Buckets[topk.BucketIndex(item, i, width)]
+			b := &me.Buckets[me.bucketIndex(item, i)]
 			if b.Fingerprint != fingerprint {
 				continue
 			}
@@ -188,27 +456,31 @@ func (me *Sketch) Incr(item string) bool {
 // Add increments the given item's count by the given increment.
 // Returns whether the item is in the top K.
 func (me *Sketch) Add(item string, increment uint32) bool {
+	return me.add(item, increment, false)
+}
+
+// add counts the item. If transient is true, the item string may be backed by memory that the caller reuses,
+// so it is copied before it is stored in the heap.
+func (me *Sketch) add(item string, increment uint32, transient bool) bool {
 	var maxSum uint32
-	fingerprint := topk.Fingerprint(item)
+	fingerprint := me.fingerprint(item)
 
-	width := me.Width
 	for i := range me.Depth {
-		k := topk.BucketIndex(item, i, width)
+		k := me.bucketIndex(item, i)
 		b := &me.Buckets[k]
 		count := b.CountsSum
 		switch {
 		// empty bucket (zero count)
 		case count == 0:
 			b.Fingerprint = fingerprint
-			clear(b.Counts)
-			b.Counts[b.First] = increment
+			b.reset(b.First, increment)
 			count = increment
 			b.CountsSum = count
 			maxSum = max(maxSum, count)
 
 		// this flow's bucket (equal fingerprint)
 		case b.Fingerprint == fingerprint:
-			b.Counts[b.First] += increment
+			b.incrementFirst(increment)
 			count += increment
 			b.CountsSum = count
 			maxSum = max(maxSum, count)
@@ -227,13 +499,12 @@ func (me *Sketch) Add(item string, increment uint32) bool {
 						float64(count/(lookupTableSize-1)))) * me.DecayLUT[count%(lookupTableSize-1)]
 				}
 				if rand.Float32() < decay {
-					countsMinIdx := b.findNonzeroMinimumCount()
-					b.Counts[countsMinIdx]--
+					b.decrementMinimum()
 					count--
 					if count == 0 {
 						b.Fingerprint = fingerprint
 						count = incrementRemaining
-						b.Counts[0] = incrementRemaining
+						b.reset(0, incrementRemaining)
 						maxSum = max(maxSum, count)
 						break
 					}
@@ -243,14 +514,46 @@ func (me *Sketch) Add(item string, increment uint32) bool {
 		}
 	}
 
+	if transient && me.Heap.WouldInsert(item, maxSum) {
+		item = strings.Clone(item)
+	}
 	return me.Heap.Update(item, fingerprint, maxSum)
 }
 
+// Remove purges the item from the sketch, e.g. to honor a deletion request:
+// it empties the item's buckets that hold its fingerprint, including their count histories, and removes the item from the top-K heap.
+//
+// Removal is best-effort: buckets only store fingerprints, so an item that collides with the removed item in a bucket
+// and has the same fingerprint loses its count there as well, and the removed item's own counts that were lost to collisions earlier
+// (i.e. are held by other items' fingerprints) can't be found.
+func (me *Sketch) Remove(item string) {
+	fingerprint := me.fingerprint(item)
+	for i := range me.Depth {
+		b := &me.Buckets[me.bucketIndex(item, i)]
+		if b.Fingerprint == fingerprint && b.CountsSum != 0 {
+			b.clearCounts()
+		}
+	}
+	me.Heap.Remove(item)
+}
+
 // Query returns whether the given item is in the top K items by count.
 func (me *Sketch) Query(item string) bool {
 	return me.Heap.Contains(item)
 }
 
+// HeapStats returns the number of items with non-zero counts in the top-K heap (see [Sketch.Iter]), its capacity K,
+// and the smallest count in the heap (0 if it is empty).
+// A heap that is always full with a high minimum count suggests that K is too small for the stream, one that rarely fills up that K is too large.
+func (me *Sketch) HeapStats() (used, capacity int, minCount uint32) {
+	for i := range me.Heap.Items {
+		if me.Heap.Items[i].Count != 0 {
+			used++
+		}
+	}
+	return used, me.K, me.Heap.Min()
+}
+
 // Iter iterates over the top K items.
 func (me *Sketch) Iter(yield func(*heap.Item) bool) {
 	for i := range me.Heap.Items {
@@ -263,16 +566,27 @@ func (me *Sketch) Iter(yield func(*heap.Item) bool) {
 	}
 }
 
+// Seq returns an iterator over the top K items and their counts in heap order, like [Sketch.Iter], skipping items with a zero count:
+//
+//	for item, count := range sketch.Seq() { ... }
+func (me *Sketch) Seq() iter.Seq2[string, uint32] {
+	return func(yield func(string, uint32) bool) {
+		me.Iter(func(item *heap.Item) bool {
+			return yield(item.Item, item.Count)
+		})
+	}
+}
+
 // SortedSlice returns the top K items as a sorted slice.
 func (me *Sketch) SortedSlice() []heap.Item {
 	out := slices.Clone(me.Heap.Items)
 
-	sort.SliceStable(out, func(i, j int) bool {
-		ci, cj := out[i].Count, out[j].Count
-		if ci == cj {
-			return out[i].Item < out[j].Item
+	// Item strings are unique, so this order is total and an unstable sort is deterministic.
+	slices.SortFunc(out, func(a, b heap.Item) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
 		}
-		return ci > cj
+		return strings.Compare(a.Item, b.Item)
 	})
 
 	end := len(out)
@@ -285,14 +599,22 @@ func (me *Sketch) SortedSlice() []heap.Item {
 	return out[:end]
 }
 
+// Drain returns the top K items as a sorted slice (like [Sketch.SortedSlice]) and then resets the sketch to an empty state.
+func (me *Sketch) Drain() []heap.Item {
+	out := me.SortedSlice()
+	me.Reset()
+	return out
+}
+
 // Reset resets the sketch to an empty state.
 func (me *Sketch) Reset() {
 	me.NextBucketToExpireIndex = 0
+	// The buckets keep their Counts buffers, so that the sketch stays usable.
 	for i := range me.Buckets {
-		me.Buckets[i].CountsSum = 0
-		me.Buckets[i].Fingerprint = 0
-		clear(me.Buckets[i].Counts)
+		me.Buckets[i].clearCounts()
+		me.Buckets[i].First = 0
 	}
-	clear(me.Buckets)
 	me.Heap.Reset()
+	me.TopKHistory = nil
+	me.TickStartUnixNano = 0
 }
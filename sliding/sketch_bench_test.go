@@ -50,6 +50,31 @@ func BenchmarkSketchAdd(b *testing.B) {
 	}
 }
 
+// BenchmarkSketchAddLongHistory benchmarks the Add method of a narrow Sketch with a long bucket history,
+// where collisions frequently decrement the smallest count in a bucket's history.
+func BenchmarkSketchAddLongHistory(b *testing.B) {
+	for _, historyLen := range []int{100, 1000} {
+		b.Run(fmt.Sprintf("HistoryLen=%d", historyLen), func(b *testing.B) {
+			sketch := sliding.New(10, historyLen,
+				sliding.WithDepth(3),
+				sliding.WithWidth(256),
+				sliding.WithDecay(0.99),
+			)
+			for i := range 10 * historyLen {
+				sketch.Add(items[rand.IntN(10_000)], uint32(1+rand.IntN(10)))
+				if i%10 == 0 {
+					sketch.Tick()
+				}
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				sketch.Add(items[rand.IntN(10_000)], uint32(1+rand.IntN(10)))
+			}
+		})
+	}
+}
+
 // BenchmarkSketchIncr benchmarks the Incr method of Sketch.
 func BenchmarkSketchIncr(b *testing.B) {
 	for _, k := range ks {
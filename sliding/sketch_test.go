@@ -3,7 +3,9 @@ package sliding_test
 import (
 	"fmt"
 	"math/rand/v2"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/keilerkonzept/topk"
@@ -104,9 +106,9 @@ func TestSketchTopKSimple(t *testing.T) {
 	sketch.Incr("Y")
 
 	expected := []heap.Item{
-		{topk.Fingerprint("X"), "X", 5},
-		{topk.Fingerprint("Y"), "Y", 4},
-		{topk.Fingerprint("Z"), "Z", 2},
+		{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 5},
+		{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 4},
+		{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 2},
 	}
 	actual := sketch.SortedSlice()
 	if diff := cmp.Diff(expected, actual); diff != "" {
@@ -136,8 +138,8 @@ func TestSketchSlidingWindowDecay(t *testing.T) {
 
 	// Check top-K after adding
 	expected := []heap.Item{
-		{topk.Fingerprint("X"), "X", 3},
-		{topk.Fingerprint("Y"), "Y", 2},
+		{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 3},
+		{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
 	}
 	actual := sketch.SortedSlice()
 	if diff := cmp.Diff(expected, actual); diff != "" {
@@ -155,8 +157,8 @@ func TestSketchSlidingWindowDecay(t *testing.T) {
 
 	// Check updated top-K
 	expected = []heap.Item{
-		{topk.Fingerprint("Z"), "Z", 3},
-		{topk.Fingerprint("Y"), "Y", 2},
+		{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 3},
+		{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
 	}
 	actual = sketch.SortedSlice()
 	if diff := cmp.Diff(expected, actual); diff != "" {
@@ -178,8 +180,8 @@ func TestSketchTopKSliding(t *testing.T) {
 	sketch.Add("Z", 1)
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("X"), "X", 3},
-			{topk.Fingerprint("Y"), "Y", 2},
+			{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 3},
+			{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -199,8 +201,8 @@ func TestSketchTopKSliding(t *testing.T) {
 	sketch.Add("Z", 1)
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("X"), "X", 5},
-			{topk.Fingerprint("Y"), "Y", 4},
+			{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 5},
+			{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 4},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -220,8 +222,8 @@ func TestSketchTopKSliding(t *testing.T) {
 	sketch.Add("Z", 3)
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("Z"), "Z", 4},
-			{topk.Fingerprint("Y"), "Y", 3},
+			{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 4},
+			{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 3},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -242,8 +244,8 @@ func TestSketchTopKSliding(t *testing.T) {
 	sketch.Add("Z", 3)
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("Z"), "Z", 6},
-			{topk.Fingerprint("Y"), "Y", 2},
+			{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 6},
+			{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 2},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -263,8 +265,8 @@ func TestSketchTopKSliding(t *testing.T) {
 	//       [ _ _ ] {z:3:y:1}
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("Z"), "Z", 3},
-			{topk.Fingerprint("Y"), "Y", 1},
+			{Fingerprint: topk.Fingerprint("Z"), Item: "Z", Count: 3},
+			{Fingerprint: topk.Fingerprint("Y"), Item: "Y", Count: 1},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -286,7 +288,7 @@ func TestSketchTopKSliding(t *testing.T) {
 	//         [ _ _ ] {x:1}
 	{
 		expected := []heap.Item{
-			{topk.Fingerprint("X"), "X", 1},
+			{Fingerprint: topk.Fingerprint("X"), Item: "X", Count: 1},
 		}
 		actual := sketch.SortedSlice()
 		if diff := cmp.Diff(expected, actual); diff != "" {
@@ -348,6 +350,17 @@ func TestSketch_Reset(t *testing.T) {
 	if len(sketch.SortedSlice()) != 0 {
 		t.Errorf("Expected no items in top-K after reset")
 	}
+
+	// The sketch keeps counting after a reset.
+	sketch.Add("item1", 5)
+	sketch.Tick()
+	sketch.Incr("item1")
+	if c := sketch.Count("item1"); c != 6 {
+		t.Errorf("Expected count = 6 after reset and add, got %d", c)
+	}
+	if items := sketch.SortedSlice(); len(items) != 1 || items[0].Item != "item1" {
+		t.Errorf("Expected item1 in top-K after reset and add, got %v", items)
+	}
 }
 
 func TestSketchErrorBounds(t *testing.T) {
@@ -442,3 +455,496 @@ func TestSketchCollisions(t *testing.T) {
 		}
 	}
 }
+
+func TestEstimateSizeBytes(t *testing.T) {
+	for _, k := range []int{1, 10, 1000} {
+		for _, width := range []int{1, 256} {
+			for _, depth := range []int{1, 3} {
+				for _, lutSize := range []int{0, 16} {
+					for _, historyLength := range []int{1, 10, 100} {
+						opts := []sliding.Option{sliding.WithWidth(width), sliding.WithDepth(depth), sliding.WithBucketHistoryLength(historyLength)}
+						if lutSize != 0 {
+							opts = append(opts, sliding.WithDecayLUTSize(lutSize))
+						}
+						expected := sliding.New(k, 100, opts...).SizeBytes()
+						actual := sliding.EstimateSizeBytes(k, width, depth, lutSize, historyLength)
+						if actual != expected {
+							t.Errorf("EstimateSizeBytes(%d, %d, %d, %d, %d) = %d, expected %d", k, width, depth, lutSize, historyLength, actual, expected)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSketch_TickTotals(t *testing.T) {
+	sketch := sliding.New(2, 3, sliding.WithWidth(1024), sliding.WithDepth(3))
+
+	if diff := cmp.Diff([]uint32{0, 0, 0}, sketch.TickTotals()); diff != "" {
+		t.Error(diff)
+	}
+
+	//t  0 1 2
+	//
+	//X  3 2 0
+	//Y  2 0 1
+	sketch.Add("X", 3)
+	sketch.Add("Y", 2)
+	sketch.Tick()
+	sketch.Add("X", 2)
+	sketch.Tick()
+	sketch.Add("Y", 1)
+	if diff := cmp.Diff([]uint32{5, 2, 1}, sketch.TickTotals()); diff != "" {
+		t.Error(diff)
+	}
+
+	sketch.Tick()
+	if diff := cmp.Diff([]uint32{2, 1, 0}, sketch.TickTotals()); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestSketch_Drain(t *testing.T) {
+	sketch := sliding.New(3, 3)
+	for i, item := range []string{"item1", "item2", "item3", "item4"} {
+		sketch.Add(item, uint32(i+1))
+		sketch.Tick()
+	}
+	expected := sketch.SortedSlice()
+
+	if diff := cmp.Diff(expected, sketch.Drain()); diff != "" {
+		t.Error(diff)
+	}
+	if len(sketch.SortedSlice()) != 0 {
+		t.Errorf("Expected no items in top-K after drain")
+	}
+	if sketch.Count("item4") != 0 {
+		t.Errorf("Expected count = 0 after drain, got %d", sketch.Count("item4"))
+	}
+}
+
+func TestSketch_Trend(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3))
+
+	up := []uint32{1, 2, 8, 16}
+	down := []uint32{16, 8, 2, 1}
+	for tick := range 4 {
+		if tick > 0 {
+			sketch.Tick()
+		}
+		sketch.Add("up", up[tick])
+		sketch.Add("down", down[tick])
+		sketch.Add("flat", 5)
+	}
+
+	if trend := sketch.Trend("up"); trend != (24.0-3.0)/4.0 {
+		t.Errorf("Expected Trend(up) = %v, got %v", (24.0-3.0)/4.0, trend)
+	}
+	if trend := sketch.Trend("down"); trend != (3.0-24.0)/25.0 {
+		t.Errorf("Expected Trend(down) = %v, got %v", (3.0-24.0)/25.0, trend)
+	}
+	if trend := sketch.Trend("flat"); trend != 0 {
+		t.Errorf("Expected Trend(flat) = 0, got %v", trend)
+	}
+	if trend := sketch.Trend("missing"); trend != 0 {
+		t.Errorf("Expected Trend(missing) = 0, got %v", trend)
+	}
+}
+
+func TestSketch_Spiking(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))
+
+	spike := []uint32{2, 3, 1, 20}
+	for tick := range 4 {
+		if tick > 0 {
+			sketch.Tick()
+		}
+		sketch.Add("spike", spike[tick])
+		sketch.Add("flat", 5)
+	}
+	sketch.Add("new", 1)
+
+	for _, tc := range []struct {
+		item     string
+		factor   float64
+		expected bool
+	}{
+		{"spike", 3, true},   // 20 > 3 * (2+3+1)/3
+		{"spike", 10, false}, // 20 <= 10 * 2
+		{"flat", 1, false},
+		{"flat", 0.5, true},
+		{"new", 100, true},
+		{"missing", 0, false},
+	} {
+		if actual := sketch.Spiking(tc.item, tc.factor); actual != tc.expected {
+			t.Errorf("Expected Spiking(%s, %v) = %v, got %v", tc.item, tc.factor, tc.expected, actual)
+		}
+	}
+}
+
+func TestSketch_WithTickHook(t *testing.T) {
+	var calls [][]heap.Item
+	sketch := sliding.New(3, 2, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithTickHook(func(topK []heap.Item) {
+		calls = append(calls, topK)
+	}))
+
+	sketch.Add("a", 3)
+	sketch.Add("b", 1)
+	sketch.Tick()
+	sketch.Add("b", 5)
+	sketch.Tick()
+	sketch.Tick()
+
+	if len(calls) != 3 {
+		t.Fatalf("Expected the hook to fire once per Tick (3 times), got %d", len(calls))
+	}
+	expected := [][]heap.Item{
+		{{Item: "a", Fingerprint: topk.Fingerprint("a"), Count: 3}, {Item: "b", Fingerprint: topk.Fingerprint("b"), Count: 1}},
+		{{Item: "b", Fingerprint: topk.Fingerprint("b"), Count: 5}},
+	}
+	if diff := cmp.Diff(expected, calls[:2]); diff != "" {
+		t.Errorf("Hook arguments mismatch (-want +got):\n%s", diff)
+	}
+	if len(calls[2]) != 0 {
+		t.Errorf("Expected an empty top K after the window has passed, got %v", calls[2])
+	}
+}
+
+func TestSketch_Churn(t *testing.T) {
+	sketch := sliding.New(2, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithTopKHistory(8))
+
+	// Stable leaders.
+	for range 4 {
+		sketch.Add("a", 10)
+		sketch.Add("b", 5)
+		sketch.Tick()
+	}
+	for ticksAgo := range 4 {
+		if churn := sketch.Churn(ticksAgo); churn != 0 {
+			t.Errorf("Expected Churn(%d) = 0 for a stable stream, got %v", ticksAgo, churn)
+		}
+	}
+
+	// Rotate the leaders.
+	for range 4 {
+		sketch.Add("c", 10)
+		sketch.Add("d", 20)
+		sketch.Tick()
+	}
+	if churn := sketch.Churn(0); churn != 0 {
+		t.Errorf("Expected Churn(0) = 0 right after a tick, got %v", churn)
+	}
+	if churn := sketch.Churn(6); churn != 1 {
+		t.Errorf("Expected Churn(6) = 1 after the leaders rotated, got %v", churn)
+	}
+	if n := len(sketch.TopKHistory); n != 8 {
+		t.Errorf("Expected 8 snapshots, got %d", n)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic for a tick beyond the history")
+		}
+	}()
+	sketch.Churn(8)
+}
+
+func TestSketch_WithHasher(t *testing.T) {
+	// "a" and "e" collide in every row of width 4; a decay of 0 keeps "e" from claiming the buckets of "a".
+	fingerprint := func(item string) uint32 { return uint32(item[0]) }
+	bucketIndex := func(item string, row, width int) int { return row*width + int(item[0])%width }
+	sketch := sliding.New(3, 4, sliding.WithWidth(4), sliding.WithDepth(2), sliding.WithDecay(0), sliding.WithHasher(fingerprint, bucketIndex))
+	sketch.Add("a", 3)
+	sketch.Add("e", 5)
+
+	if c := sketch.Count("a"); c != 3 {
+		t.Errorf("Expected Count(a) = 3, got %d", c)
+	}
+	if c := sketch.Count("e"); c != 0 {
+		t.Errorf("Expected Count(e) = 0, got %d", c)
+	}
+	for _, b := range sketch.Buckets {
+		if b.CountsSum != 0 && b.Fingerprint != 'a' {
+			t.Errorf("Unexpected bucket fingerprint %d", b.Fingerprint)
+		}
+	}
+}
+
+// scanningBucket is a reference model of a single-bucket sketch with a decay of 1,
+// which re-scans the bucket history for the smallest non-zero count on every decrement.
+type scanningBucket struct {
+	fingerprint uint32
+	counts      []uint32
+	first       int
+}
+
+func (me *scanningBucket) add(fingerprint, increment uint32) {
+	sum := uint32(0)
+	for _, c := range me.counts {
+		sum += c
+	}
+	switch {
+	case sum == 0:
+		me.fingerprint = fingerprint
+		clear(me.counts)
+		me.counts[me.first] = increment
+	case me.fingerprint == fingerprint:
+		me.counts[me.first] += increment
+	default:
+		for remaining := increment; remaining > 0; remaining-- {
+			minIdx := -1
+			for j := range me.counts {
+				i := (me.first + j) % len(me.counts)
+				if me.counts[i] != 0 && (minIdx < 0 || me.counts[i] < me.counts[minIdx]) {
+					minIdx = i
+				}
+			}
+			me.counts[minIdx]--
+			if sum--; sum == 0 {
+				me.fingerprint = fingerprint
+				me.counts[0] = remaining
+				return
+			}
+		}
+	}
+}
+
+func (me *scanningBucket) tick() {
+	if !slices.ContainsFunc(me.counts, func(c uint32) bool { return c != 0 }) {
+		return
+	}
+	me.first = (me.first + len(me.counts) - 1) % len(me.counts)
+	me.counts[me.first] = 0
+}
+
+func TestSketch_DecrementsSmallestCount(t *testing.T) {
+	const historyLen = 8
+	sketch := sliding.New(2, historyLen, sliding.WithWidth(1), sliding.WithDepth(1), sliding.WithDecay(1))
+	model := scanningBucket{counts: make([]uint32, historyLen)}
+	r := rand.New(rand.NewPCG(1, 2))
+	for step := range 5000 {
+		if r.IntN(4) == 0 {
+			sketch.Tick()
+			model.tick()
+		} else {
+			item := []string{"a", "b", "c"}[r.IntN(3)]
+			increment := uint32(r.IntN(6)) // including zero increments
+			sketch.Add(item, increment)
+			model.add(topk.Fingerprint(item), increment)
+		}
+		b := sketch.Buckets[0]
+		if b.CountsSum > 0 && b.Fingerprint != model.fingerprint {
+			t.Fatalf("step %d: expected fingerprint %d, got %d", step, model.fingerprint, b.Fingerprint)
+		}
+		if diff := cmp.Diff(model.counts, b.Counts); diff != "" {
+			t.Fatalf("step %d: counts mismatch (-scanning +cached):\n%s", step, diff)
+		}
+	}
+}
+
+func TestSketch_Clone(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0), sliding.WithTopKHistory(2))
+	sketch.Add("a", 10)
+	sketch.Tick()
+	sketch.Add("b", 5)
+
+	clone := sketch.Clone()
+	if diff := cmp.Diff(sketch.SortedSlice(), clone.SortedSlice()); diff != "" {
+		t.Errorf("Clone mismatch (-original +clone):\n%s", diff)
+	}
+
+	sketch.Add("b", 20)
+	clone.Add("a", 1)
+	clone.Ticks(4)
+
+	if c := sketch.Count("b"); c != 25 {
+		t.Errorf("Expected Count(b) = 25 in the original, got %d", c)
+	}
+	if c := sketch.Count("a"); c != 10 {
+		t.Errorf("Expected Count(a) = 10 in the original, got %d", c)
+	}
+	if items := clone.SortedSlice(); len(items) != 0 {
+		t.Errorf("Expected the clone's counts to have aged out, got %v", items)
+	}
+	if n := len(sketch.TopKHistory); n != 1 {
+		t.Errorf("Expected 1 snapshot in the original's history, got %d", n)
+	}
+}
+
+func TestSketch_Equal(t *testing.T) {
+	newSketch := func(opts ...sliding.Option) *sliding.Sketch {
+		return sliding.New(3, 4, append([]sliding.Option{sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0)}, opts...)...)
+	}
+	sketch := newSketch()
+	sketch.Add("a", 10)
+	sketch.Tick()
+	sketch.Add("b", 5)
+	sketch.Add("c", 20)
+
+	// The same items in another order, giving another heap order, and with another (derived) decay LUT.
+	reordered := newSketch(sliding.WithDecayLUTSize(16))
+	reordered.Add("a", 10)
+	reordered.Tick()
+	reordered.Add("c", 20)
+	reordered.Add("b", 5)
+	if !sketch.Equal(reordered) || !reordered.Equal(sketch) {
+		t.Error("Expected sketches with the same items to be equal regardless of heap order")
+	}
+	if !sketch.Equal(sketch.Clone()) {
+		t.Error("Expected a sketch to equal its clone")
+	}
+
+	if sketch.Equal(sliding.New(3, 8, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))) {
+		t.Error("Expected sketches of different window sizes to differ")
+	}
+
+	higher := sketch.Clone()
+	higher.Add("b", 1)
+	if sketch.Equal(higher) || higher.Equal(sketch) {
+		t.Error("Expected sketches with different counts to differ")
+	}
+
+	// The same counts in another tick of the window.
+	shifted := newSketch()
+	shifted.Add("a", 10)
+	shifted.Add("b", 5)
+	shifted.Add("c", 20)
+	if sketch.Equal(shifted) {
+		t.Error("Expected sketches with different count histories to differ")
+	}
+}
+
+func TestSketch_AddAt(t *testing.T) {
+	const tick = time.Second
+	t0 := time.Unix(1_700_000_000, 0)
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0), sliding.WithTickDuration(tick))
+
+	sketch.AddAt("a", 5, t0)
+	sketch.AddAt("b", 3, t0.Add(2500*time.Millisecond)) // 2 ticks after a
+	// An out-of-order event is counted in the current tick.
+	sketch.AddAt("b", 2, t0.Add(tick))
+	if c := sketch.Count("b"); c != 5 {
+		t.Errorf("Expected Count(b) = 5, got %d", c)
+	}
+
+	// 4 ticks after t0, a has left the window, but b is still in it.
+	sketch.AdvanceTo(t0.Add(4 * tick))
+	if c := sketch.Count("a"); c != 0 {
+		t.Errorf("Expected Count(a) = 0 after a window, got %d", c)
+	}
+	if c := sketch.Count("b"); c != 5 {
+		t.Errorf("Expected Count(b) = 5, got %d", c)
+	}
+
+	// A far-future timestamp expires the window without ticking once per elapsed tick.
+	sketch.AddAt("c", 1, t0.Add(100*365*24*time.Hour))
+	if c := sketch.Count("b"); c != 0 {
+		t.Errorf("Expected Count(b) = 0 after a far-future event, got %d", c)
+	}
+	if c := sketch.Count("c"); c != 1 {
+		t.Errorf("Expected Count(c) = 1, got %d", c)
+	}
+	expected := t0.Add(100 * 365 * 24 * time.Hour).UnixNano()
+	if sketch.TickStartUnixNano != expected {
+		t.Errorf("Expected the current tick to start at %d, got %d", expected, sketch.TickStartUnixNano)
+	}
+}
+
+func TestSketch_AddAt_WithoutTickDuration(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))
+	t0 := time.Unix(1_700_000_000, 0)
+	sketch.AddAt("a", 5, t0)
+	sketch.AddAt("a", 5, t0.Add(time.Hour))
+	if c := sketch.Count("a"); c != 10 {
+		t.Errorf("Expected Count(a) = 10 without ticks, got %d", c)
+	}
+}
+
+func TestSketch_HeapStats(t *testing.T) {
+	sketch := sliding.New(3, 2, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))
+	type stats struct {
+		Used, Capacity int
+		MinCount       uint32
+	}
+	heapStats := func() stats {
+		used, capacity, minCount := sketch.HeapStats()
+		return stats{used, capacity, minCount}
+	}
+
+	sketch.Add("a", 10)
+	sketch.Add("b", 5)
+	if diff := cmp.Diff(stats{2, 3, 5}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+	sketch.Tick()
+	sketch.Add("c", 7)
+	sketch.Add("d", 8)
+	if diff := cmp.Diff(stats{3, 3, 7}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+	// After another tick, only c and d are left in the window.
+	sketch.Tick()
+	if diff := cmp.Diff(stats{2, 3, 7}, heapStats()); diff != "" {
+		t.Errorf("HeapStats mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSketch_Remove(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))
+	sketch.Add("a", 10)
+	sketch.Tick()
+	sketch.Add("a", 2)
+	sketch.Add("b", 5)
+
+	sketch.Remove("a")
+	sketch.Remove("missing")
+	if sketch.Query("a") || sketch.Count("a") != 0 {
+		t.Errorf("Expected a to be removed, got Query = %v, Count = %d", sketch.Query("a"), sketch.Count("a"))
+	}
+	if diff := cmp.Diff([]heap.Item{{Fingerprint: topk.Fingerprint("b"), Item: "b", Count: 5}}, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+
+	// A removed item starts over when it is counted again, and its old history doesn't resurface on ticks.
+	sketch.Add("a", 1)
+	sketch.Tick()
+	if c := sketch.Count("a"); c != 1 {
+		t.Errorf("Expected Count(a) = 1 after re-adding, got %d", c)
+	}
+}
+
+func TestSketch_CountAndQuery(t *testing.T) {
+	sketch := sliding.New(3, 4, sliding.WithWidth(16), sliding.WithDepth(2))
+	r := rand.New(rand.NewPCG(3, 4))
+	for i := range 1000 {
+		sketch.Add(fmt.Sprintf("item%d", r.IntN(50)), uint32(1+r.IntN(5)))
+		if i%100 == 99 {
+			sketch.Tick()
+		}
+	}
+	for i := range 60 {
+		item := fmt.Sprintf("item%d", i)
+		count, inTopK := sketch.CountAndQuery(item)
+		if count != sketch.Count(item) || inTopK != sketch.Query(item) {
+			t.Errorf("CountAndQuery(%q) = (%d, %v), but Count = %d and Query = %v", item, count, inTopK, sketch.Count(item), sketch.Query(item))
+		}
+	}
+}
+
+func TestSketch_Seq(t *testing.T) {
+	sketch := sliding.New(3, 2, sliding.WithWidth(1024), sliding.WithDepth(3), sliding.WithDecay(0))
+	sketch.Add("a", 3)
+	sketch.Tick()
+	sketch.Add("b", 2)
+	sketch.Add("c", 1)
+	sketch.Tick() // a ages out of the window
+
+	got := map[string]uint32{}
+	for item, count := range sketch.Seq() {
+		got[item] = count
+	}
+	if diff := cmp.Diff(map[string]uint32{"b": 2, "c": 1}, got); diff != "" {
+		t.Errorf("Seq mismatch (-want +got):\n%s", diff)
+	}
+}
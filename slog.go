@@ -0,0 +1,29 @@
+package topk
+
+import "log/slog"
+
+const defaultLogItems = 5
+
+// LogValue implements [slog.LogValuer]: logging a sketch produces a group with its geometry, size, total count,
+// and its top items (the top 5 unless the [WithLogItems] option is set), keyed by item with their counts as values.
+func (me *Sketch) LogValue() slog.Value {
+	n := me.LogItems
+	if n == 0 {
+		n = defaultLogItems
+	}
+	items := me.SortedSlice()
+	items = items[:min(max(n, 0), len(items))]
+	top := make([]any, 0, len(items))
+	for _, item := range items {
+		top = append(top, slog.Uint64(item.Item, uint64(item.Count)))
+	}
+	return slog.GroupValue(
+		slog.Int("k", me.K),
+		slog.Int("width", me.Width),
+		slog.Int("depth", me.Depth),
+		slog.Float64("decay", float64(me.Decay)),
+		slog.Int("size_bytes", me.SizeBytes()),
+		slog.Uint64("total_count", me.Total),
+		slog.Group("top", top...),
+	)
+}
@@ -0,0 +1,42 @@
+package topk_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_LogValue(t *testing.T) {
+	sketch := topk.New(5, topk.WithWidth(64), topk.WithDepth(2), topk.WithDecay(0.5), topk.WithLogItems(2))
+	sketch.Add("a", 30)
+	sketch.Add("b", 20)
+	sketch.Add("c", 10)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("topk", "sketch", sketch)
+
+	var record struct {
+		Sketch map[string]any `json:"sketch"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"k":           5.0,
+		"width":       64.0,
+		"depth":       2.0,
+		"decay":       0.5,
+		"size_bytes":  float64(sketch.SizeBytes()),
+		"total_count": 60.0,
+		"top":         map[string]any{"a": 30.0, "b": 20.0},
+	}
+	if diff := cmp.Diff(expected, record.Sketch); diff != "" {
+		t.Errorf("Logged attributes mismatch (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,77 @@
+package topk
+
+import "github.com/keilerkonzept/topk/heap"
+
+// SpaceSaving is a top-k counter implementing the Space-Saving algorithm [1], an alternative to the probabilistic [Sketch].
+//
+// It monitors at most K items in a min-heap. Counting an unmonitored item when the heap is full evicts the item with the minimum count m,
+// and the new item takes over the count m (plus its increment), recording m as its maximum over-estimation error.
+// This gives deterministic guarantees:
+//   - counts never under-estimate: for a monitored item, Count - Error <= true count <= Count;
+//   - every item whose true count exceeds N/K (for a total count N) is monitored.
+//
+// [1] Metwally, Agrawal, El Abbadi: "Efficient Computation of Frequent and Top-k Elements in Data Streams" (ICDT 2005)
+type SpaceSaving struct {
+	K      int               // Number of monitored items.
+	Heap   *heap.Min         // Monitored items, ordered by count.
+	Errors map[string]uint32 // Maximum over-estimation error of each monitored item.
+}
+
+// NewSpaceSaving returns a Space-Saving counter that monitors up to `k` items.
+func NewSpaceSaving(k int) *SpaceSaving {
+	return &SpaceSaving{
+		K:      k,
+		Heap:   heap.NewMin(k),
+		Errors: make(map[string]uint32, k),
+	}
+}
+
+// Incr counts a single instance of the given item.
+func (me *SpaceSaving) Incr(item string) bool {
+	return me.Add(item, 1)
+}
+
+// Add increments the given item's count by the given increment.
+// Returns whether the item is in the top K (which is always the case right after adding it).
+func (me *SpaceSaving) Add(item string, increment uint32) bool {
+	if b := me.Heap.Get(item); b != nil {
+		return me.Heap.Update(item, b.Fingerprint, addSaturating(b.Count, increment))
+	}
+
+	var minCount uint32
+	if me.Heap.Full() {
+		minCount = me.Heap.Min()
+		delete(me.Errors, me.Heap.Items[0].Item)
+	}
+	me.Errors[item] = minCount
+	return me.Heap.Update(item, Fingerprint(item), addSaturating(minCount, increment))
+}
+
+// Count returns the (over-)estimated count of the given item, or 0 if it is not monitored.
+func (me *SpaceSaving) Count(item string) uint32 {
+	if b := me.Heap.Get(item); b != nil {
+		return b.Count
+	}
+	return 0
+}
+
+// Error returns the maximum over-estimation error of the given item's count, or 0 if it is not monitored.
+func (me *SpaceSaving) Error(item string) uint32 {
+	return me.Errors[item]
+}
+
+// Query returns whether the given item is monitored, i.e. in the top K items by count.
+func (me *SpaceSaving) Query(item string) bool {
+	return me.Heap.Contains(item)
+}
+
+// SortedSlice returns the monitored items as a slice sorted by descending count.
+func (me *SpaceSaving) SortedSlice() []heap.Item {
+	return sortedItems(me.Heap.Items)
+}
+
+// Reset resets the counter to an empty state.
+func (me *SpaceSaving) Reset() {
+	me.Heap.Reset()
+	clear(me.Errors)
+}
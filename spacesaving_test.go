@@ -0,0 +1,59 @@
+package topk_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSpaceSaving(t *testing.T) {
+	const k = 20
+	r := rand.New(rand.NewPCG(1, 2))
+	sketch := topk.NewSpaceSaving(k)
+
+	// A few frequent items in a stream of mostly unique noise.
+	frequent := map[string]uint32{"f0": 3000, "f1": 2000, "f2": 1000}
+	var stream []string
+	for item, count := range frequent {
+		for range count {
+			stream = append(stream, item)
+		}
+	}
+	for i := range 10_000 {
+		stream = append(stream, fmt.Sprintf("noise%d", i%5_000))
+	}
+	r.Shuffle(len(stream), func(i, j int) { stream[i], stream[j] = stream[j], stream[i] })
+
+	truth := make(map[string]uint32)
+	for _, item := range stream {
+		sketch.Incr(item)
+		truth[item]++
+	}
+
+	n := uint32(len(stream))
+	for item, count := range truth {
+		if count > n/k && !sketch.Query(item) {
+			t.Errorf("Expected %s (count %d > N/K = %d) to be monitored", item, count, n/k)
+		}
+	}
+	for _, b := range sketch.SortedSlice() {
+		count, errBound := b.Count, sketch.Error(b.Item)
+		if count < truth[b.Item] {
+			t.Errorf("%s: expected no under-estimation, got %d < %d", b.Item, count, truth[b.Item])
+		}
+		if count-errBound > truth[b.Item] {
+			t.Errorf("%s: expected Count - Error <= true count, got %d - %d > %d", b.Item, count, errBound, truth[b.Item])
+		}
+	}
+	items := sketch.SortedSlice()
+	if len(items) != k {
+		t.Fatalf("Expected %d monitored items, got %d", k, len(items))
+	}
+	for i, item := range []string{"f0", "f1", "f2"} {
+		if items[i].Item != item {
+			t.Errorf("Expected %s at rank %d, got %s", item, i, items[i].Item)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package topk
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// statsdNameReplacer replaces the characters that separate names, values, types, and lines in the StatsD line protocol,
+// as well as Graphite's path separator and whitespace.
+var statsdNameReplacer = strings.NewReplacer(
+	".", "_",
+	":", "_",
+	"|", "_",
+	"@", "_",
+	"#", "_",
+	" ", "_",
+	"\t", "_",
+	"\r", "_",
+	"\n", "_",
+)
+
+// StatsDName returns the item string sanitized for use as a metric name component in the StatsD line protocol,
+// with all separator characters (and whitespace) replaced by underscores.
+func StatsDName(item string) string {
+	return statsdNameReplacer.Replace(item)
+}
+
+// WriteStatsD writes the top K items as StatsD gauges, one `prefix.item:count|g` line per item in descending count order.
+// Item strings are sanitized with [StatsDName]; if the prefix is empty, the lines start with the item name.
+func (me *Sketch) WriteStatsD(w io.Writer, prefix string) error {
+	bw := bufio.NewWriter(w)
+	for _, item := range me.SortedSlice() {
+		if prefix != "" {
+			bw.WriteString(prefix)
+			bw.WriteByte('.')
+		}
+		bw.WriteString(StatsDName(item.Item))
+		bw.WriteByte(':')
+		bw.WriteString(strconv.FormatUint(uint64(item.Count), 10))
+		bw.WriteString("|g\n")
+	}
+	return bw.Flush()
+}
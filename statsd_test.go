@@ -0,0 +1,42 @@
+package topk_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSketch_WriteStatsD(t *testing.T) {
+	sketch := topk.New(3, topk.WithWidth(1024), topk.WithDecay(0))
+	sketch.Add("home", 30)
+	sketch.Add("api/v1:users|list", 20)
+	sketch.Add("www.example.com", 10)
+
+	var sb strings.Builder
+	if err := sketch.WriteStatsD(&sb, "topk.pages"); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "topk.pages.home:30|g\n" +
+		"topk.pages.api/v1_users_list:20|g\n" +
+		"topk.pages.www_example_com:10|g\n"
+	if diff := cmp.Diff(expected, sb.String()); diff != "" {
+		t.Errorf("Output mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestStatsDName(t *testing.T) {
+	for item, expected := range map[string]string{
+		"plain":        "plain",
+		"a.b":          "a_b",
+		"a:b|c@d#e":    "a_b_c_d_e",
+		"line\nbreak ": "line_break_",
+	} {
+		if actual := topk.StatsDName(item); actual != expected {
+			t.Errorf("Expected StatsDName(%q) = %q, got %q", item, expected, actual)
+		}
+	}
+}
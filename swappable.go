@@ -0,0 +1,35 @@
+package topk
+
+import "sync/atomic"
+
+// SwappableSketch holds a [Sketch] that can be replaced atomically, e.g. by a sketch rebuilt in the background with a different geometry.
+// Readers that [SwappableSketch.Load] the sketch see either the old or the new one, never a partially built sketch.
+//
+// Swapping only makes the replacement atomic: the sketches themselves are not safe for concurrent use,
+// so a sketch must not be modified after it is stored, unless its users synchronize access to it.
+// The zero value holds no sketch.
+type SwappableSketch struct {
+	sketch atomic.Pointer[Sketch]
+}
+
+// NewSwappable returns a [SwappableSketch] holding the given sketch.
+func NewSwappable(sketch *Sketch) *SwappableSketch {
+	var s SwappableSketch
+	s.sketch.Store(sketch)
+	return &s
+}
+
+// Load returns the current sketch, or nil if none has been stored.
+func (me *SwappableSketch) Load() *Sketch {
+	return me.sketch.Load()
+}
+
+// Store replaces the current sketch with the given one.
+func (me *SwappableSketch) Store(sketch *Sketch) {
+	me.sketch.Store(sketch)
+}
+
+// Swap replaces the current sketch with the given one and returns the previous sketch.
+func (me *SwappableSketch) Swap(sketch *Sketch) *Sketch {
+	return me.sketch.Swap(sketch)
+}
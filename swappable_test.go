@@ -0,0 +1,64 @@
+package topk_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/keilerkonzept/topk"
+)
+
+func TestSwappableSketch(t *testing.T) {
+	old := topk.New(3, topk.WithWidth(64), topk.WithDepth(2), topk.WithDecay(0))
+	old.Add("old", 10)
+	rebuilt := topk.New(5, topk.WithWidth(256), topk.WithDepth(3), topk.WithDecay(0))
+	rebuilt.Add("new", 20)
+
+	var zero topk.SwappableSketch
+	if zero.Load() != nil {
+		t.Fatal("expected the zero value to hold no sketch")
+	}
+
+	s := topk.NewSwappable(old)
+	if s.Load() != old {
+		t.Fatal("expected the initial sketch")
+	}
+
+	const readers = 8
+	const reads = 10_000
+	var wg sync.WaitGroup
+	errs := make(chan string, readers)
+	for range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range reads {
+				switch sketch := s.Load(); {
+				case sketch == old && sketch.K == 3 && sketch.Width == 64 && sketch.Count("old") == 10:
+				case sketch == rebuilt && sketch.K == 5 && sketch.Width == 256 && sketch.Count("new") == 20:
+				default:
+					errs <- "loaded a sketch that is neither the old nor the new one"
+					return
+				}
+			}
+		}()
+	}
+	for i := range reads {
+		if i%2 == 0 {
+			s.Store(rebuilt)
+		} else {
+			s.Store(old)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	if previous := s.Swap(rebuilt); previous != old {
+		t.Error("expected Swap to return the previous sketch")
+	}
+	if s.Load() != rebuilt {
+		t.Error("expected the swapped-in sketch")
+	}
+}
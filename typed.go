@@ -0,0 +1,79 @@
+package topk
+
+// TypedItem is a top-K item of a [Typed] sketch, with the original value, its key, and its estimated count.
+type TypedItem[T any] struct {
+	Value T
+	Key   string
+	Count uint32
+}
+
+// Typed counts values of any type T in a string-keyed [Sketch], by projecting each value to a string key.
+// Values with equal keys are counted as one item; for each top-K item, Typed keeps the value most recently added under its key.
+//
+// Unlike [Generic], which re-implements the sketch for comparable items, Typed is a thin facade over a [Sketch],
+// so it keeps the string sketch's optimizations and options at the cost of computing a key per Add.
+type Typed[T any] struct {
+	Sketch *Sketch // Underlying string-keyed sketch.
+	key    func(T) string
+	values map[string]T // Values of the top-K items (and of some evicted ones, until they are pruned), by key.
+}
+
+// NewTyped returns a sketch for values of type T with the given `k` (number of top items to keep) and key function.
+// It accepts the same options as [New], with the same defaults.
+func NewTyped[T any](k int, key func(T) string, opts ...Option) *Typed[T] {
+	return &Typed[T]{
+		Sketch: New(k, opts...),
+		key:    key,
+		values: make(map[string]T, k),
+	}
+}
+
+// Incr counts a single instance of the given value.
+func (me *Typed[T]) Incr(value T) bool {
+	return me.Add(value, 1)
+}
+
+// Add increments the given value's count by the given increment.
+// Returns whether the value is in the top K.
+func (me *Typed[T]) Add(value T, increment uint32) bool {
+	key := me.key(value)
+	if !me.Sketch.Add(key, increment) {
+		return false
+	}
+	me.values[key] = value
+	// Values of evicted items are kept until they could make up the majority of the map.
+	if len(me.values) > 2*me.Sketch.K {
+		for key := range me.values {
+			if !me.Sketch.Heap.Contains(key) {
+				delete(me.values, key)
+			}
+		}
+	}
+	return true
+}
+
+// Count returns the estimated count of the given value.
+func (me *Typed[T]) Count(value T) uint32 {
+	return me.Sketch.Count(me.key(value))
+}
+
+// Query returns whether the given value is in the top K items by count.
+func (me *Typed[T]) Query(value T) bool {
+	return me.Sketch.Query(me.key(value))
+}
+
+// SortedSlice returns the top K items as a slice sorted by descending count (and by key for equal counts), see [Sketch.SortedSlice].
+func (me *Typed[T]) SortedSlice() []TypedItem[T] {
+	items := me.Sketch.SortedSlice()
+	out := make([]TypedItem[T], len(items))
+	for i, item := range items {
+		out[i] = TypedItem[T]{Value: me.values[item.Item], Key: item.Item, Count: item.Count}
+	}
+	return out
+}
+
+// Reset resets the sketch to an empty state.
+func (me *Typed[T]) Reset() {
+	me.Sketch.Reset()
+	clear(me.values)
+}
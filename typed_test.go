@@ -0,0 +1,46 @@
+package topk_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/keilerkonzept/topk"
+)
+
+type endpoint struct {
+	Method string
+	Path   string
+}
+
+func TestTyped(t *testing.T) {
+	key := func(e endpoint) string { return e.Method + " " + e.Path }
+	sketch := topk.NewTyped(2, key, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+
+	for i := range 20 {
+		sketch.Incr(endpoint{Method: "GET", Path: fmt.Sprintf("/noise/%d", i)})
+	}
+	sketch.Add(endpoint{Method: "GET", Path: "/"}, 10)
+	sketch.Add(endpoint{Method: "POST", Path: "/login"}, 5)
+	sketch.Incr(endpoint{Method: "POST", Path: "/login"})
+
+	expected := []topk.TypedItem[endpoint]{
+		{Value: endpoint{Method: "GET", Path: "/"}, Key: "GET /", Count: 10},
+		{Value: endpoint{Method: "POST", Path: "/login"}, Key: "POST /login", Count: 6},
+	}
+	if diff := cmp.Diff(expected, sketch.SortedSlice()); diff != "" {
+		t.Errorf("SortedSlice mismatch (-want +got):\n%s", diff)
+	}
+	if !sketch.Query(endpoint{Method: "GET", Path: "/"}) || sketch.Query(endpoint{Method: "GET", Path: "/noise/3"}) {
+		t.Error("Expected only the top-K values to be in the top K")
+	}
+	if c := sketch.Count(endpoint{Method: "POST", Path: "/login"}); c != 6 {
+		t.Errorf("Expected Count = 6, got %d", c)
+	}
+
+	sketch.Reset()
+	if items := sketch.SortedSlice(); len(items) != 0 {
+		t.Errorf("Expected no items after Reset, got %v", items)
+	}
+}
@@ -0,0 +1,10 @@
+package wide
+
+import "unsafe"
+
+const (
+	sizeofSketchStruct = int(unsafe.Sizeof(Sketch{}))
+	sizeofBucketStruct = int(unsafe.Sizeof(Bucket{}))
+	sizeofMinStruct    = int(unsafe.Sizeof(Min{}))
+	sizeofItemStruct   = int(unsafe.Sizeof(Item{}))
+)
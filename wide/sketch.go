@@ -0,0 +1,237 @@
+// Package wide implements a top-k sketch like [topk.Sketch], but with 64-bit counters,
+// for long-running sketches whose counts would overflow the 32-bit counters of [topk.Sketch].
+//
+// The 64-bit counters double the memory of the buckets (16 instead of 8 bytes per bucket, including padding),
+// which usually dominate the sketch's size,
+// and add 8 bytes per top-K item. The API mirrors the core API of [topk.Sketch], and items are hashed the same way.
+package wide
+
+import (
+	"cmp"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/internal/sizeof"
+)
+
+const defaultDecayLUTSize = 256
+
+// Bucket is a single sketch counter together with the corresponding item's fingerprint.
+type Bucket struct {
+	Fingerprint uint32
+	Count       uint64
+}
+
+// Sketch is a top-k sketch with 64-bit counters.
+// The entire structure is serializable using any serialization method - all fields and sub-structs are exported and can be reasonably serialized.
+type Sketch struct {
+	K     int // Keep track of top `K` items in the min-heap..
+	Width int // Number of buckets per hash function.
+	Depth int // Number of hash functions.
+
+	// `math.Pow(Decay, i)` is the probability that a flow's counter with value `i` is decremented on collision.
+	Decay float32
+	// Look-up table for powers of `Decay`. The value at `i` is `math.Pow(Decay, i)`
+	DecayLUT []float32
+
+	Buckets []Bucket // Sketch counters.
+	Heap    *Min     // Top-K min-heap.
+}
+
+// New returns a top-k sketch with 64-bit counters and the given `k` (number of top items to keep).
+//
+//   - The depth defaults to `max(3, log(k))` unless the [WithDepth] option is set.
+//   - The width defaults to `max(256, k*log(k))` unless the [WithWidth] option is set.
+//   - The decay parameter defaults to 0.9 unless the [WithDecay] option is set.
+//   - The decay LUT size defaults to 256 unless the [WithDecayLUTSize] option is set.
+func New(k int, opts ...Option) *Sketch {
+	log_k := int(math.Log(float64(k)))
+	k_log_k := int(float64(k) * math.Log(float64(k)))
+
+	// default settings
+	out := Sketch{
+		K:     k,
+		Width: max(256, k_log_k),
+		Depth: max(3, log_k),
+		Decay: 0.9,
+	}
+
+	for _, o := range opts {
+		o(&out)
+	}
+
+	if len(out.DecayLUT) == 0 {
+		// if not specified, default to 256
+		out.DecayLUT = make([]float32, defaultDecayLUTSize)
+	}
+
+	out.Heap = NewMin(out.K)
+	out.Buckets = make([]Bucket, out.Width*out.Depth)
+	for i := range out.DecayLUT {
+		out.DecayLUT[i] = float32(math.Pow(float64(out.Decay), float64(i)))
+	}
+
+	return &out
+}
+
+// SizeBytes returns the current size of the sketch in bytes.
+func (me *Sketch) SizeBytes() int {
+	size := sizeofSketchStruct + sizeofMinStruct +
+		len(me.Buckets)*sizeofBucketStruct +
+		len(me.DecayLUT)*sizeof.Float32 +
+		cap(me.Heap.Items)*sizeofItemStruct + sizeof.StringIntMap +
+		(sizeof.Int+sizeof.String)*len(me.Heap.Index)
+	for i := range me.Heap.Items {
+		size += len(me.Heap.Items[i].Item)
+	}
+	return size
+}
+
+// Count returns the estimated count of the given item.
+func (me *Sketch) Count(item string) uint64 {
+	if i := me.Heap.Find(item); i >= 0 {
+		return me.Heap.Items[i].Count
+	}
+
+	fingerprint := topk.Fingerprint(item)
+	var maxCount uint64
+	for i := range me.Depth {
+		b := &me.Buckets[topk.BucketIndex(item, i, me.Width)]
+		if b.Fingerprint != fingerprint {
+			continue
+		}
+		maxCount = max(maxCount, b.Count)
+	}
+	return maxCount
+}
+
+// Incr counts a single instance of the given item.
+func (me *Sketch) Incr(item string) bool {
+	return me.Add(item, 1)
+}
+
+// Add increments the given item's count by the given increment.
+// Returns whether the item is in the top K.
+func (me *Sketch) Add(item string, increment uint64) bool {
+	var maxCount uint64
+	fingerprint := topk.Fingerprint(item)
+
+	for i := range me.Depth {
+		b := &me.Buckets[topk.BucketIndex(item, i, me.Width)]
+		switch {
+		// empty bucket (zero count)
+		case b.Count == 0:
+			b.Fingerprint = fingerprint
+			b.Count = increment
+			maxCount = max(maxCount, increment)
+		// this flow's bucket (equal fingerprint)
+		case b.Fingerprint == fingerprint:
+			b.Count += increment
+			maxCount = max(maxCount, b.Count)
+		// another flow's bucket (nonequal fingerprint)
+		default:
+			maxCount = max(maxCount, me.decayBucket(b, fingerprint, increment))
+		}
+	}
+
+	return me.Heap.Update(item, fingerprint, maxCount)
+}
+
+// decayBucket counts the increment in a bucket holding another item's fingerprint,
+// decaying the bucket's counter with probability `Decay^count` for each unit of the increment.
+// If the counter drops to zero, the item takes over the bucket with the rest of the increment, which is returned; otherwise 0 is returned.
+//
+// Instead of drawing once per unit, which would take forever for large increments, it draws the number of units until the next decrement
+// from the geometric distribution, so it takes one step per decrement (and a single step if every unit decrements).
+func (me *Sketch) decayBucket(b *Bucket, fingerprint uint32, increment uint64) uint64 {
+	count := b.Count
+	for remaining := increment; remaining > 0; {
+		p := float64(me.decayProbability(count))
+		if p <= 0 {
+			break
+		}
+		if p >= 1 {
+			// Decay^count only grows as the count drops, so every remaining unit decrements the counter.
+			if remaining < count {
+				count -= remaining
+				break
+			}
+			return me.takeOver(b, fingerprint, remaining-count+1)
+		}
+		// Number of units up to and including the one that decrements the counter.
+		units := math.Ceil(math.Log(1-rand.Float64()) / math.Log1p(-p))
+		if units > float64(remaining) {
+			break
+		}
+		remaining -= max(1, uint64(units)) - 1
+		count--
+		if count == 0 {
+			return me.takeOver(b, fingerprint, remaining)
+		}
+		remaining--
+	}
+	b.Count = count
+	return 0
+}
+
+// takeOver assigns the bucket to the fingerprint with the given count, and returns the count.
+func (me *Sketch) takeOver(b *Bucket, fingerprint uint32, count uint64) uint64 {
+	b.Fingerprint = fingerprint
+	b.Count = count
+	return count
+}
+
+// decayProbability returns `Decay^count`, the probability of decrementing a counter with the given value on collision.
+func (me *Sketch) decayProbability(count uint64) float32 {
+	lookupTableSize := uint64(len(me.DecayLUT))
+	if count < lookupTableSize {
+		return me.DecayLUT[count]
+	}
+	return float32(math.Pow(
+		float64(me.DecayLUT[lookupTableSize-1]),
+		float64(count/(lookupTableSize-1)))) * me.DecayLUT[count%(lookupTableSize-1)]
+}
+
+// Query returns whether the given item is in the top K items by count.
+func (me *Sketch) Query(item string) bool {
+	return me.Heap.Contains(item)
+}
+
+// Iter iterates over the top K items.
+func (me *Sketch) Iter(yield func(*Item) bool) {
+	for i := range me.Heap.Items {
+		if me.Heap.Items[i].Count == 0 {
+			continue
+		}
+		if !yield(&me.Heap.Items[i]) {
+			break
+		}
+	}
+}
+
+// SortedSlice returns the top K items as a slice sorted by descending count and then by item.
+func (me *Sketch) SortedSlice() []Item {
+	out := slices.Clone(me.Heap.Items)
+	slices.SortFunc(out, func(a, b Item) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Item, b.Item)
+	})
+	end := len(out)
+	for ; end > 0; end-- {
+		if out[end-1].Count > 0 {
+			break
+		}
+	}
+	return out[:end]
+}
+
+// Reset resets the sketch to an empty state.
+func (me *Sketch) Reset() {
+	clear(me.Buckets)
+	me.Heap.Reset()
+}
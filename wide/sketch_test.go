@@ -0,0 +1,124 @@
+package wide_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/keilerkonzept/topk"
+	"github.com/keilerkonzept/topk/internal/sizeof"
+	"github.com/keilerkonzept/topk/wide"
+)
+
+func TestSketch_CountBeyondUint32(t *testing.T) {
+	sketch := wide.New(3, wide.WithWidth(1024), wide.WithDepth(3))
+	sketch.Add("a", math.MaxUint32)
+	sketch.Add("b", 10)
+	sketch.Add("a", math.MaxUint32)
+	sketch.Incr("a")
+
+	const expected = 2*math.MaxUint32 + 1
+	if c := sketch.Count("a"); c != expected {
+		t.Errorf("Expected Count(a) = %d, got %d", uint64(expected), c)
+	}
+	items := sketch.SortedSlice()
+	if len(items) != 2 || items[0].Item != "a" || items[0].Count != expected {
+		t.Errorf("Expected a to lead the top K with count %d, got %v", uint64(expected), items)
+	}
+}
+
+func TestSketch_MatchesTopk(t *testing.T) {
+	// Without decay and collisions, both sketches count exactly.
+	sketch := wide.New(5, wide.WithWidth(1024), wide.WithDepth(3), wide.WithDecay(0))
+	narrow := topk.New(5, topk.WithWidth(1024), topk.WithDepth(3), topk.WithDecay(0))
+	for i := range 1000 {
+		item := fmt.Sprintf("item%d", i%20)
+		sketch.Add(item, uint64(1+i%20))
+		narrow.Add(item, uint32(1+i%20))
+	}
+
+	var expected, actual []string
+	for _, item := range narrow.SortedSlice() {
+		expected = append(expected, fmt.Sprintf("%s=%d", item.Item, item.Count))
+	}
+	for _, item := range sketch.SortedSlice() {
+		actual = append(actual, fmt.Sprintf("%s=%d", item.Item, item.Count))
+	}
+	if diff := cmp.Diff(expected, actual); diff != "" {
+		t.Errorf("Top K mismatch (-topk +wide):\n%s", diff)
+	}
+	for i := range 20 {
+		item := fmt.Sprintf("item%d", i)
+		if expected, actual := uint64(narrow.Count(item)), sketch.Count(item); actual != expected {
+			t.Errorf("Expected Count(%s) = %d, got %d", item, expected, actual)
+		}
+	}
+
+	sketch.Reset()
+	if len(sketch.SortedSlice()) != 0 || sketch.Query("item0") {
+		t.Error("Expected an empty sketch after Reset")
+	}
+}
+
+func TestSketch_LargeIncrementCollision(t *testing.T) {
+	for _, decay := range []float32{0.9, 1} {
+		t.Run(fmt.Sprintf("Decay=%v", decay), func(t *testing.T) {
+			// A single bucket makes b collide with a; this must not take one step per unit of the increment.
+			sketch := wide.New(2, wide.WithWidth(1), wide.WithDepth(1), wide.WithDecay(decay))
+			sketch.Add("a", 5)
+			sketch.Add("b", 1<<40)
+			b := sketch.Buckets[0]
+			// The takeover happens within the first few hundred units with overwhelming probability.
+			if b.Fingerprint != topk.Fingerprint("b") || b.Count < 1<<40-1000 || b.Count > 1<<40-4 {
+				t.Errorf("Expected b to take over the bucket with about 2^40, got %+v", b)
+			}
+			if decay == 1 && b.Count != 1<<40-4 {
+				t.Errorf("Expected b to take over the bucket with exactly 2^40 - 4, got %d", b.Count)
+			}
+		})
+	}
+}
+
+func TestSketch_DecayMatchesPerUnitDecay(t *testing.T) {
+	// The batched decay must take over buckets as often as decaying once per unit of the increment.
+	const decay, initial, increment, trials = 0.8, 10, 40, 5000
+	r := rand.New(rand.NewPCG(1, 2))
+	perUnit := 0
+	for range trials {
+		count := uint64(initial)
+		for range increment {
+			if r.Float64() < math.Pow(decay, float64(count)) {
+				if count--; count == 0 {
+					perUnit++
+					break
+				}
+			}
+		}
+	}
+
+	batched := 0
+	for range trials {
+		sketch := wide.New(2, wide.WithWidth(1), wide.WithDepth(1), wide.WithDecay(decay))
+		sketch.Add("a", initial)
+		sketch.Add("b", increment)
+		if sketch.Buckets[0].Fingerprint == topk.Fingerprint("b") {
+			batched++
+		}
+	}
+	if expected, actual := float64(perUnit)/trials, float64(batched)/trials; math.Abs(expected-actual) > 0.04 {
+		t.Errorf("Expected a takeover rate of about %.3f, got %.3f", expected, actual)
+	}
+}
+
+func TestSketch_SizeBytes(t *testing.T) {
+	sketch := wide.New(4, wide.WithWidth(16), wide.WithDepth(2))
+	empty := sketch.SizeBytes()
+	sketch.Add("ab", 1)
+	sketch.Add("cde", 1)
+	// Like heap.Min.SizeBytes, each top-K item adds its key bytes and an index map entry.
+	if expected := empty + len("ab") + len("cde") + 2*(sizeof.Int+sizeof.String); sketch.SizeBytes() != expected {
+		t.Errorf("Expected SizeBytes = %d, got %d", expected, sketch.SizeBytes())
+	}
+}